@@ -0,0 +1,147 @@
+// Package alert checks the CBS rates against thresholds such as USD>14.5, and
+// their moves since the last day against ones such as USD:2.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gitlab.com/eoea/cbsrates/rates"
+)
+
+// ErrNoRate: returned by Check when the alert's currency is not among the
+// records.
+var ErrNoRate = errors.New("no rate to check")
+
+// operators: the comparison operators of an alert; the two-character ones come
+// first so that >= is not taken for >.
+var operators = []string{">=", "<=", ">", "<"}
+
+// opChange: the Op of a change alert, e.g. USD:2 for a move of more than 2%.
+const opChange = ":"
+
+// Alert: a threshold on the rate of a currency, e.g. USD>14.5, or on how much
+// it moved since the last day, as a percentage, e.g. USD:2.
+type Alert struct {
+	Currency string
+	Op       string
+	Value    float64
+}
+
+// String: returns the alert as it is written, e.g. USD>14.5 or USD:2.
+func (a Alert) String() string {
+	return a.Currency + a.Op + strconv.FormatFloat(a.Value, 'f', -1, 64)
+}
+
+// Parse: parses an alert of the form <currency><op><value>, where op is one of
+// >, <, >= or <=, or a change alert of the form <currency>:<percent>.
+func Parse(expr string) (Alert, error) {
+	if curr, pct, ok := strings.Cut(expr, opChange); ok {
+		curr = strings.ToUpper(strings.TrimSpace(curr))
+		if len(curr) != 3 {
+			return Alert{}, fmt.Errorf("invalid alert %q: %q is not a currency code", expr, curr)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(pct), "%"), 64)
+		if err != nil || v <= 0 {
+			return Alert{}, fmt.Errorf("invalid alert %q: %q is not a positive percentage", expr, pct)
+		}
+		return Alert{Currency: curr, Op: opChange, Value: v}, nil
+	}
+	for _, op := range operators {
+		curr, value, ok := strings.Cut(expr, op)
+		if !ok {
+			continue
+		}
+		curr = strings.ToUpper(strings.TrimSpace(curr))
+		if len(curr) != 3 {
+			return Alert{}, fmt.Errorf("invalid alert %q: %q is not a currency code", expr, curr)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return Alert{}, fmt.Errorf("invalid alert %q: %q is not a number", expr, value)
+		}
+		return Alert{Currency: curr, Op: op, Value: v}, nil
+	}
+	return Alert{}, fmt.Errorf("invalid alert %q: must be <currency><op><value> with op one of %s, or <currency>:<percent>", expr, strings.Join(operators, ", "))
+}
+
+// IsChange: reports whether the alert is on the move of the rate since the
+// last day rather than on the rate itself.
+func (a Alert) IsChange() bool {
+	return a.Op == opChange
+}
+
+// Moved: returns the change from prev to rate as a percentage of prev, and
+// whether it is more than the change alert's threshold either way.
+func (a Alert) Moved(rate, prev float64) (float64, bool) {
+	if prev == 0 {
+		return 0, false
+	}
+	pct := (rate - prev) / prev * 100
+	return pct, math.Abs(pct) > a.Value
+}
+
+// Crossed: reports whether rate is past the alert's threshold. It is always
+// false for a change alert, which Moved checks instead.
+func (a Alert) Crossed(rate float64) bool {
+	switch a.Op {
+	case ">":
+		return rate > a.Value
+	case "<":
+		return rate < a.Value
+	case ">=":
+		return rate >= a.Value
+	case "<=":
+		return rate <= a.Value
+	}
+	return false
+}
+
+// Rate: returns the mid, buying or selling rate of the alert's currency in
+// records, or an error wrapping ErrNoRate if CBS did not publish it.
+func (a Alert) Rate(records []rates.RateRecord, side string) (float64, error) {
+	for _, r := range records {
+		if r.Currency != a.Currency {
+			continue
+		}
+		var rate string
+		switch side {
+		case "mid":
+			rate = r.MidRate
+		case "buying":
+			rate = r.Buying
+		case "selling":
+			rate = r.Selling
+		default:
+			return 0, fmt.Errorf("unknown rate side %q: must be mid, buying or selling", side)
+		}
+		if rate == "" {
+			break
+		}
+		return strconv.ParseFloat(rate, 64)
+	}
+	return 0, fmt.Errorf("%w: %s has no %s rate", ErrNoRate, a.Currency, side)
+}
+
+// CheckSide: reports whether the given side, mid, buying or selling, of the
+// rate in the alert expr has crossed its threshold.
+func CheckSide(expr, side string, records []rates.RateRecord) (bool, error) {
+	a, err := Parse(expr)
+	if err != nil {
+		return false, err
+	}
+	rate, err := a.Rate(records, side)
+	if err != nil {
+		return false, err
+	}
+	return a.Crossed(rate), nil
+}
+
+// Check: reports whether the mid-rate in the alert expr, e.g. USD>14.5, has
+// crossed its threshold.
+func Check(expr string, records []rates.RateRecord) (bool, error) {
+	return CheckSide(expr, "mid", records)
+}
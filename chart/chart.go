@@ -0,0 +1,29 @@
+// Package chart draws the rates in a terminal.
+package chart
+
+import "strings"
+
+// sparks: the block characters of a sparkline, from the lowest to the highest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline: returns a line of one block character per value, its height
+// relative to the lowest and highest of values. Values that are all the same
+// are drawn halfway up.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := len(sparks) / 2
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
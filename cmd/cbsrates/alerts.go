@@ -0,0 +1,76 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/alert"
+	"gitlab.com/eoea/cbsrates/parser"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/rates"
+)
+
+// errRateMoved: returned by printCurrent, after the rates are printed, when a
+// change -alert such as USD:2 fired.
+var errRateMoved = errors.New("a rate moved more than its -alert allows since the last day")
+
+// exitRateMoved: the exit status for errRateMoved, so that a cron job can act
+// only on notable moves.
+const exitRateMoved = 4
+
+// checkAlerts: prints a warning to stderr for each -alert whose threshold the
+// opts.side rate, the mid-rate by default, has crossed, or that it moved past
+// since prev, the rates of the last day keyed by currency. It reports whether
+// a change alert fired. With -alert-notify the rates are then sent on as after
+// a fetch, unless notified says this run's fetch already did.
+func checkAlerts(opts options, rs []cbsrates.Rate, prev map[string]cbsrates.Rate, date time.Time, notified bool) bool {
+	// The rates are checked as published, not as rounded for printing.
+	records := make([]rates.RateRecord, 0, len(rs))
+	for _, rate := range rs {
+		records = append(records, parser.Record(rate))
+	}
+	prevRecords := make([]rates.RateRecord, 0, len(prev))
+	for _, rate := range prev {
+		prevRecords = append(prevRecords, parser.Record(rate))
+	}
+	side := cmp.Or(opts.side, "mid")
+	fired, moved := false, false
+	for _, expr := range opts.alerts {
+		// The alerts were checked when parsing the flags.
+		a, _ := alert.Parse(expr)
+		rate, err := a.Rate(records, side)
+		if err != nil {
+			slog.Warn("could not check alert", "alert", expr, "err", err)
+			continue
+		}
+		if !a.IsChange() {
+			if a.Crossed(rate) {
+				fired = true
+				fmt.Fprintf(os.Stderr, "*** ALERT: %s %s rate is %s (%s) ***\n", a.Currency, side, formatRate(rate), a)
+			}
+			continue
+		}
+		prevRate, err := a.Rate(prevRecords, side)
+		if err != nil {
+			slog.Warn("could not check alert, no rate of the last day", "alert", expr, "err", err)
+			continue
+		}
+		if pct, ok := a.Moved(rate, prevRate); ok {
+			fired, moved = true, true
+			fmt.Fprintf(os.Stderr, "*** ALERT: %s %s rate moved %+.2f%% since the last day, from %s to %s (%s) ***\n",
+				a.Currency, side, pct, formatRate(prevRate), formatRate(rate), a)
+		}
+	}
+	if fired && opts.alertNotify && !notified {
+		if opts.dryRun {
+			fmt.Fprintln(os.Stderr, "Would send the rates to the webhooks and -smtp-to")
+			return moved
+		}
+		notify(opts, rs, date)
+	}
+	return moved
+}
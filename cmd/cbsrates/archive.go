@@ -0,0 +1,65 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// fetchHistory: fetches the pages of the CBS archive at -url, following its
+// "next page" links every -delay, and stores the rates of those dated between
+// the two days of -fetch-history in db, as the rates of the day each page was
+// published for. Each page is taken to be laid out like the daily rates page.
+// The archive may list the days either way round, so the pages are followed
+// until they go past the range in the direction they run, or run out.
+func fetchHistory(opts options, db *sql.DB) error {
+	fetching.Add(1)
+	defer fetching.Done()
+
+	s, err := cbsrates.NewScraper(opts.fetchOptions())
+	if err != nil {
+		return fmt.Errorf("%w: %w", errFetchFailed, err)
+	}
+	defer s.Close()
+
+	from, to := opts.fetchHistory[0], opts.fetchHistory[1]
+	var last time.Time
+	saved := 0
+	err = s.FetchPages(runCtx, opts.url, opts.delay, func(ratesHTML string) (bool, error) {
+		published, err := cbsrates.PublishedDate(ratesHTML)
+		if err != nil {
+			return false, fmt.Errorf("an archive page does not say which day it is for: %w", err)
+		}
+		day := published.Format("2006-01-02")
+		rates, err := cbsrates.ParseRates(ratesHTML)
+		switch {
+		case err != nil:
+			slog.Warn("no rates found on the archive page", "date", day, "err", err)
+		case !published.Before(from) && !published.After(to):
+			if err := saveRatesOn(db, published, clock(), rates); err != nil {
+				return false, err
+			}
+			saved++
+			slog.Info("stored the archived rates", "date", day)
+		default:
+			slog.Debug("skipped the archived rates, outside the range", "date", day)
+		}
+
+		backwards := !last.IsZero() && published.Before(last)
+		forwards := !last.IsZero() && published.After(last)
+		last = published
+		return !(backwards && published.Before(from) || forwards && published.After(to)), nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errFetchFailed, err)
+	}
+	if saved == 0 {
+		return errors.New("the archive has no rates between the -fetch-history dates")
+	}
+	slog.Info("fetched the archived rates", "days", saved)
+	return nil
+}
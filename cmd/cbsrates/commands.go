@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// commands: the subcommands, each short for the flags in its description.
+// Without one, cbsrates fetches as with fetch. Cobra adds completion, which
+// prints the completion script of a shell.
+var commands = []struct {
+	use, short string
+}{
+	{"fetch", "print the current rates; the default"},
+	{"history [N]", "same as -history N, 7 by default"},
+	{"fetch-history FROM TO", "same as -fetch-history FROM,TO, e.g. fetch-history 2024-01-01 2024-03-31"},
+	{"convert AMOUNT...", "same as -convert \"AMOUNT...\", e.g. convert 100 USD to SCR"},
+	{"cross FROM TO", "same as -cross FROM/TO, e.g. cross EUR USD"},
+	{"serve [ADDR]", "same as -serve ADDR, :8080 by default"},
+}
+
+// newCommand: returns the cbsrates command and its subcommands, which all call
+// run with the name of the subcommand, empty for none, and their positional
+// arguments; the flags go in its persistent flags, so that they can be given
+// with any subcommand. Errors are left to the caller to print.
+func newCommand(run func(command string, args []string) error) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "cbsrates",
+		Short:         "cbsrates prints the Central Bank of Seychelles exchange rates",
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return run("", args)
+		},
+	}
+	for _, c := range commands {
+		root.AddCommand(&cobra.Command{
+			Use:   c.use,
+			Short: c.short,
+			Args:  cobra.ArbitraryArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return run(cmd.Name(), args)
+			},
+		})
+	}
+	return root
+}
+
+// longFlags: returns args with the flags of fs given with a single dash, as
+// the flag package took them, e.g. -json or -watch=5m, given with two as
+// pflag takes them. The values of the flags are left alone, even if they
+// start with a dash, e.g. -precision -1, and so is everything after --.
+func longFlags(fs *pflag.FlagSet, args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if !strings.HasPrefix(arg, "-") || len(arg) == 1 {
+			out = append(out, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+		var f *pflag.Flag
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			f = fs.Lookup(name)
+		case len(name) == 1:
+			f = fs.ShorthandLookup(name)
+		case fs.ShorthandLookup(name[:1]) == nil:
+			// Not a shorthand with its value run on, e.g. -orates.csv.
+			arg, f = "-"+arg, fs.Lookup(name)
+		}
+		out = append(out, arg)
+		if f != nil && !hasValue && f.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// applyCommand: sets the flags in fs that command and its positional
+// arguments are short for.
+func applyCommand(fs *pflag.FlagSet, command string, args []string) error {
+	maxArgs := map[string]int{"fetch": 0, "history": 1, "fetch-history": 2, "cross": 2, "serve": 1, "": 0}
+	if n, ok := maxArgs[command]; ok && len(args) > n {
+		return fmt.Errorf("unexpected argument %q; flag values go after an =, e.g. -watch=5m", args[n])
+	}
+	switch command {
+	case "history":
+		days := "7"
+		if len(args) == 1 {
+			days = args[0]
+		}
+		return setFlag(fs, "history", days)
+	case "convert":
+		if len(args) == 0 {
+			return fmt.Errorf("convert needs an amount, e.g. convert 100 USD")
+		}
+		return setFlag(fs, "convert", strings.Join(args, " "))
+	case "fetch-history":
+		if len(args) != 2 {
+			return fmt.Errorf("fetch-history needs two dates, e.g. fetch-history 2024-01-01 2024-03-31")
+		}
+		return setFlag(fs, "fetch-history", args[0]+","+args[1])
+	case "cross":
+		if len(args) != 2 {
+			return fmt.Errorf("cross needs two currencies, e.g. cross EUR USD")
+		}
+		return setFlag(fs, "cross", args[0]+"/"+args[1])
+	case "serve":
+		addr := ":8080"
+		if len(args) == 1 {
+			addr = args[0]
+		}
+		return setFlag(fs, "serve", addr)
+	}
+	return nil
+}
+
+// setFlag: sets the flag name in fs to value, naming the flag in the error as
+// parsing the command line does.
+func setFlag(fs *pflag.FlagSet, name, value string) error {
+	if err := fs.Set(name, value); err != nil {
+		return fmt.Errorf("invalid value %q for -%s: %w", value, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// addTestFlags: adds a few of the flags of cbsrates to fs.
+func addTestFlags(fs *pflag.FlagSet) {
+	fs.Bool("json", false, "")
+	fs.Int("history", 0, "")
+	fs.Int("precision", 4, "")
+	fs.String("convert", "", "")
+	fs.String("file", "", "")
+	fs.StringP("output", "o", "", "")
+}
+
+func TestLongFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("cbsrates", pflag.ContinueOnError)
+	addTestFlags(fs)
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"-json history 3", "--json history 3"},
+		{"-precision -1 -file -", "--precision -1 --file -"},
+		{"-precision=-1 -o -x.csv", "--precision=-1 -o -x.csv"},
+		{"-orates.csv --json", "-orates.csv --json"},
+		{"-help -h -jsn", "--help -h --jsn"},
+		{"-file saved.html -- -json", "--file saved.html -- -json"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(longFlags(fs, strings.Fields(tt.args)), " "); got != tt.want {
+			t.Errorf("longFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		args       string
+		command    string
+		positional []string
+	}{
+		{"", "", nil},
+		{"-json", "", nil},
+		{"history 3 -json", "history", []string{"3"}},
+		{"-json convert 100 USD", "convert", []string{"100", "USD"}},
+		{"-file -", "", nil},
+		{"convert 100 USD -", "convert", []string{"100", "USD", "-"}},
+	}
+	for _, tt := range tests {
+		var command string
+		var positional []string
+		cmd := newCommand(func(c string, args []string) error {
+			command, positional = c, args
+			return nil
+		})
+		cmd.SetOutput(io.Discard)
+		addTestFlags(cmd.PersistentFlags())
+		cmd.SetArgs(longFlags(cmd.PersistentFlags(), strings.Fields(tt.args)))
+		if err := cmd.Execute(); err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if command != tt.command || !slices.Equal(positional, tt.positional) {
+			t.Errorf("%q ran %q, %q, want %q, %q", tt.args, command, positional, tt.command, tt.positional)
+		}
+	}
+}
+
+func TestApplyCommandInvalidValue(t *testing.T) {
+	fs := pflag.NewFlagSet("cbsrates", pflag.ContinueOnError)
+	addTestFlags(fs)
+	err := applyCommand(fs, "history", []string{"abc"})
+	if err == nil || !strings.Contains(err.Error(), "-history") {
+		t.Errorf("applyCommand(history abc) error = %v, want one naming -history", err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile: returns ~/.config/cbsrates/config.yaml, or the equivalent
+// user configuration directory on other systems.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cbsrates", "config.yaml")
+}
+
+// flagGroups: the flags that choose the same thing in different ways, e.g.
+// -csv for -format csv, so that one given on the command line overrides the
+// others in the config file too.
+var flagGroups = [][]string{
+	{"format", "json", "csv", "table", "yaml"},
+}
+
+// applyConfig: sets the flags of fs from the YAML configuration file at path.
+// Each key of the file is the name of a flag, e.g.
+//
+//	currencies: [USD, EUR]
+//	cache: /var/cache/cbsrates.html
+//	timeout: 1m
+//
+// Flags given on the command line are left alone so that they take precedence
+// over the file, and so are their aliases and the flags of the same
+// flagGroups. A missing file is only an error if required is set.
+func applyConfig(fs *pflag.FlagSet, path string, required bool) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	var config map[string]any
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+
+	set := make(map[string]bool)
+	// Only the flags are shared with the command's own flag set, where they
+	// are parsed, so fs.Visit would not see them.
+	fs.VisitAll(func(given *pflag.Flag) {
+		if !given.Changed {
+			return
+		}
+		// An alias sets the same value as the flag it stands for.
+		fs.VisitAll(func(f *pflag.Flag) {
+			if f.Value == given.Value {
+				set[f.Name] = true
+			}
+		})
+		for _, group := range flagGroups {
+			if slices.Contains(group, given.Name) {
+				for _, name := range group {
+					set[name] = true
+				}
+			}
+		}
+	})
+
+	for name, value := range config {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("config file %s: unknown option %q", path, name)
+		}
+		if set[name] {
+			continue
+		}
+		if err := fs.Set(name, configValue(value)); err != nil {
+			return fmt.Errorf("config file %s: invalid %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// configValue: formats a YAML value as it would be given on the command line;
+// lists become comma-separated.
+func configValue(value any) string {
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = fmt.Sprint(item)
+	}
+	return strings.Join(items, ",")
+}
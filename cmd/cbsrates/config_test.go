@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApplyConfigCommandLineWins(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		check  func(file, format string, csv, json bool, ttl time.Duration) bool
+	}{
+		{
+			name:   "alias",
+			config: "file: /nonexistent.html\n",
+			args:   []string{"-from-file", "good.html"},
+			check:  func(file, _ string, _, _ bool, _ time.Duration) bool { return file == "good.html" },
+		},
+		{
+			name:   "duration alias",
+			config: "ttl: 1h\n",
+			args:   []string{"-max-age", "72h"},
+			check:  func(_, _ string, _, _ bool, ttl time.Duration) bool { return ttl == 72*time.Hour },
+		},
+		{
+			name:   "shortcut",
+			config: "format: json\n",
+			args:   []string{"-csv"},
+			check: func(_, format string, csv, _ bool, _ time.Duration) bool {
+				return csv && format == "text"
+			},
+		},
+		{
+			name:   "format over a shortcut",
+			config: "json: true\n",
+			args:   []string{"-format", "table"},
+			check: func(_, format string, _, json bool, _ time.Duration) bool {
+				return !json && format == "table"
+			},
+		},
+		{
+			name:   "no command line",
+			config: "file: saved.html\nformat: yaml\n",
+			check: func(file, format string, _, _ bool, _ time.Duration) bool {
+				return file == "saved.html" && format == "yaml"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			fs := pflag.NewFlagSet("cbsrates", pflag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			var file, format string
+			var csv, json bool
+			var ttl time.Duration
+			fs.StringVar(&file, "file", "", "")
+			fs.StringVar(&file, "from-file", "", "")
+			fs.StringVar(&format, "format", "text", "")
+			fs.BoolVar(&csv, "csv", false, "")
+			fs.BoolVar(&json, "json", false, "")
+			fs.DurationVar(&ttl, "ttl", 0, "")
+			fs.DurationVar(&ttl, "max-age", 0, "")
+			if err := fs.Parse(longFlags(fs, tt.args)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConfig(fs, path, true); err != nil {
+				t.Fatalf("applyConfig: %v", err)
+			}
+			if !tt.check(file, format, csv, json, ttl) {
+				t.Errorf("after the config: file %q, format %q, csv %v, json %v, ttl %v", file, format, csv, json, ttl)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// sideRate: returns the buying, selling or mid-rate of rate, or an error if
+// CBS did not publish it.
+func sideRate(rate cbsrates.Rate, side string) (float64, error) {
+	var v float64
+	switch side {
+	case "mid":
+		v = rate.MidRate
+	case "buying":
+		v = rate.Buying
+	case "selling":
+		v = rate.Selling
+	default:
+		return 0, fmt.Errorf("unknown rate side %q: must be mid, buying or selling", side)
+	}
+	if v == 0 {
+		return 0, fmt.Errorf("no %s %s rate available", rate.Currency, side)
+	}
+	return v, nil
+}
+
+// convert: converts amount to SCR if toSCR is set, or from SCR to the rate's
+// currency otherwise, using the given side of the rate.
+func convert(amount float64, rate cbsrates.Rate, side string, toSCR bool) (float64, error) {
+	v, err := sideRate(rate, side)
+	if err != nil {
+		return 0, err
+	}
+	if toSCR {
+		return amount * v, nil
+	}
+	return amount / v, nil
+}
+
+// parseConversion: parses a -convert value, which is an amount optionally
+// followed by its currency and the currency to convert it to: "100", "100 USD",
+// "100 USD to SCR" or "1000 SCR to USD". It returns the currency converted
+// from into SCR, or the one converted to from SCR; both are empty if only an
+// amount is given.
+func parseConversion(text string) (amount float64, from, to string, err error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0, "", "", errors.New("no amount given")
+	}
+	amount, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil || amount <= 0 || math.IsInf(amount, 0) {
+		return 0, "", "", fmt.Errorf("%q is not a positive amount", fields[0])
+	}
+
+	switch {
+	case len(fields) == 1:
+		return amount, "", "", nil
+	case len(fields) == 2:
+		from = strings.ToUpper(fields[1])
+		if from == "SCR" {
+			return 0, "", "", fmt.Errorf("%q does not say which currency to convert SCR to, e.g. %q", text, fields[0]+" SCR to USD")
+		}
+		return amount, from, "", nil
+	case len(fields) == 4 && strings.EqualFold(fields[2], "to"):
+		a, b := strings.ToUpper(fields[1]), strings.ToUpper(fields[3])
+		switch {
+		case a == "SCR" && b != "SCR":
+			return amount, "", b, nil
+		case b == "SCR" && a != "SCR":
+			return amount, a, "", nil
+		}
+		return 0, "", "", fmt.Errorf("%q must convert to or from SCR", text)
+	}
+	return 0, "", "", fmt.Errorf("%q must be an amount, optionally followed by its currency and \"to\" another, e.g. %q", text, "100 USD to SCR")
+}
+
+// printConversion: converts amount from or to SCR with the rates in ratesHTML
+// and prints the result to w. Exactly one of from and to must be set. Unless a
+// side is given, amounts are converted at the buying rate into SCR and at the
+// selling rate from SCR.
+func printConversion(w io.Writer, amount float64, from, to, side, ratesHTML string) error {
+	curr := from + to
+	if from != "" {
+		side = cmp.Or(side, "buying")
+	} else {
+		side = cmp.Or(side, "selling")
+	}
+
+	rate, err := cbsrates.ParseCurrency(curr, ratesHTML)
+	if err != nil {
+		return fmt.Errorf("no %s rates found: %w", curr, err)
+	}
+
+	result, err := convert(amount, rate, side, from != "")
+	if err != nil {
+		return err
+	}
+	if from != "" {
+		fmt.Fprintf(w, "%.2f %s = %.2f SCR\n", amount, curr, result)
+	} else {
+		fmt.Fprintf(w, "%.2f SCR = %.2f %s\n", amount, result, curr)
+	}
+	return nil
+}
+
+// parseCross: parses a -cross value, two currency codes separated by a slash,
+// a comma or a space, e.g. EUR/USD.
+func parseCross(text string) (from, to string, err error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == '/' || r == ',' || r == ' ' })
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("%q is not two currencies, e.g. EUR/USD", text)
+	}
+	currencies, err := parseCurrencies(strings.Join(fields, ","))
+	if err != nil {
+		return "", "", err
+	}
+	return currencies[0], currencies[1], nil
+}
+
+// printCross: prints to w how much of to one unit of from is worth, worked out
+// from their mid-rates against SCR in ratesHTML. CBS does not publish such
+// cross-rates, so the result is labelled as indicative.
+func printCross(w io.Writer, from, to, ratesHTML string) error {
+	var mids [2]float64
+	for i, curr := range []string{from, to} {
+		rate, err := cbsrates.ParseCurrency(curr, ratesHTML)
+		if err != nil {
+			return fmt.Errorf("no %s rates found: %w", curr, err)
+		}
+		if mids[i], err = sideRate(rate, "mid"); err != nil {
+			return fmt.Errorf("cannot work out the %s/%s cross-rate: %w", from, to, err)
+		}
+	}
+	fmt.Fprintf(w, "1 %s = %s %s (indicative cross-rate via SCR mid-rates)\n", from, formatRate(mids[0]/mids[1]), to)
+	return nil
+}
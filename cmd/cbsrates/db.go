@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	_ "modernc.org/sqlite"
+)
+
+// openDB: opens the SQLite history database at path, creating the rates table
+// if it does not exist yet.
+//
+// The table holds one row per currency per day, in date, which is the local
+// date of fetched_at except for the archived rates of -fetch-history; saving
+// the rates again on the same day updates that row. The history is read by
+// date.
+func openDB(path string) (*sql.DB, error) {
+	// _time_format=sqlite stores times in a layout that SQLite's date
+	// functions understand.
+	db, err := sql.Open("sqlite", path+"?_time_format=sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS rates (
+		fetched_at DATETIME,
+		currency TEXT,
+		buying REAL,
+		selling REAL,
+		mid_rate REAL,
+		date TEXT,
+		effective_date TEXT
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create rates table: %w", err)
+	}
+	if err := migrateDB(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not upgrade database: %w", err)
+	}
+	return db, nil
+}
+
+// migrateDB: adds the date column to a rates table created before it existed,
+// keeping only the last row of each currency per day, and makes sure that
+// date and currency are unique together. Such tables stored fetched_at in Go's
+// time.Time.String layout, which is rewritten in the SQLite one. The
+// effective_date column is added too, left NULL for the rows already there.
+func migrateDB(db *sql.DB) error {
+	var hasDate bool
+	if err := db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('rates') WHERE name = 'date'`).Scan(&hasDate); err != nil {
+		return err
+	}
+	if !hasDate {
+		stmts := []string{
+			`ALTER TABLE rates ADD COLUMN date TEXT`,
+			`UPDATE rates SET fetched_at = substr(fetched_at, 1, 19) || '+00:00'`,
+			`UPDATE rates SET date = date(fetched_at, 'localtime')`,
+			`DELETE FROM rates WHERE rowid NOT IN (SELECT max(rowid) FROM rates GROUP BY date, currency)`,
+		}
+		for _, stmt := range stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS rates_date_currency ON rates (date, currency)`); err != nil {
+		return err
+	}
+
+	var hasEffectiveDate bool
+	if err := db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('rates') WHERE name = 'effective_date'`).Scan(&hasEffectiveDate); err != nil {
+		return err
+	}
+	if !hasEffectiveDate {
+		_, err := db.Exec(`ALTER TABLE rates ADD COLUMN effective_date TEXT`)
+		return err
+	}
+	return nil
+}
+
+// nullable: returns nil for a rate that was not published so that it is
+// stored as NULL.
+func nullable(v float64) any {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// saveRates: inserts one row per currency into the rates table, replacing the
+// currency's row for the same day if there is one. The day is that of
+// fetchedAt, while effective_date is the one CBS published the rates for.
+// Currencies without any published rate are skipped.
+func saveRates(db *sql.DB, fetchedAt time.Time, rates []cbsrates.Rate) error {
+	return saveRatesOn(db, fetchedAt, fetchedAt, rates)
+}
+
+// saveRatesOn: saves the rates as saveRates does, but as the rates of day
+// rather than of the day they were fetched, e.g. for those of the archive.
+func saveRatesOn(db *sql.DB, day, fetchedAt time.Time, rates []cbsrates.Rate) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	date := day.Local().Format("2006-01-02")
+	// Stored in UTC to the second so that fetched_at sorts as text.
+	fetchedAt = fetchedAt.UTC().Truncate(time.Second)
+	for _, rate := range rates {
+		if rate.Buying == 0 && rate.Selling == 0 && rate.MidRate == 0 {
+			continue
+		}
+		var effectiveDate any
+		if !rate.Date.IsZero() {
+			effectiveDate = rate.Date.Format("2006-01-02")
+		}
+		_, err := tx.Exec(`INSERT INTO rates (fetched_at, currency, buying, selling, mid_rate, date, effective_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (date, currency) DO UPDATE SET
+				fetched_at = excluded.fetched_at,
+				buying = excluded.buying,
+				selling = excluded.selling,
+				mid_rate = excluded.mid_rate,
+				effective_date = excluded.effective_date`,
+			fetchedAt, rate.Currency, nullable(rate.Buying), nullable(rate.Selling), nullable(rate.MidRate), date, effectiveDate)
+		if err != nil {
+			return fmt.Errorf("could not save %s rates: %w", rate.Currency, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// printHistory: prints the rates stored for the last days for each currency
+// to w, by the day they are for, with the time they were fetched unless that
+// was on another day, as with the archived rates of -fetch-history.
+func printHistory(w io.Writer, db *sql.DB, currencies []string, days int) error {
+	since := clock().AddDate(0, 0, -days).Format("2006-01-02")
+	for _, curr := range currencies {
+		rows, err := db.Query(`SELECT date, fetched_at, buying, selling, mid_rate FROM rates
+			WHERE currency = ? AND date >= ? ORDER BY date`, curr, since)
+		if err != nil {
+			return fmt.Errorf("could not query %s history: %w", curr, err)
+		}
+
+		fmt.Fprintln(w, "Currency:", curr)
+		for rows.Next() {
+			var day string
+			var fetchedAt time.Time
+			var buying, selling, midRate sql.NullFloat64
+			if err := rows.Scan(&day, &fetchedAt, &buying, &selling, &midRate); err != nil {
+				rows.Close()
+				return fmt.Errorf("could not read %s history: %w", curr, err)
+			}
+			when := day
+			if fetchedAt.Local().Format("2006-01-02") == day {
+				when = fetchedAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%-16s  Buying: %-8s Selling: %-8s Mid-rate: %s\n",
+				when, displayRate(buying.Float64), displayRate(selling.Float64), displayRate(midRate.Float64))
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// previousRate: returns the rates stored for curr for the most recent day
+// before that of the given time, or a Rate with no rates if there are none.
+func previousRate(db *sql.DB, curr string, before time.Time) (cbsrates.Rate, error) {
+	var buying, selling, midRate sql.NullFloat64
+	err := db.QueryRow(`SELECT buying, selling, mid_rate FROM rates
+		WHERE currency = ? AND date < ? ORDER BY date DESC LIMIT 1`,
+		curr, before.Format("2006-01-02")).Scan(&buying, &selling, &midRate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cbsrates.Rate{Currency: curr}, nil
+	}
+	if err != nil {
+		return cbsrates.Rate{}, fmt.Errorf("could not query previous %s rates: %w", curr, err)
+	}
+	return cbsrates.Rate{Currency: curr, Buying: buying.Float64, Selling: selling.Float64, MidRate: midRate.Float64}, nil
+}
+
+// midRates: returns the mid-rates of curr stored for the last days, oldest
+// first, leaving out the days CBS did not publish one.
+func midRates(db *sql.DB, curr string, days int) ([]float64, error) {
+	since := clock().AddDate(0, 0, -days).Format("2006-01-02")
+	rows, err := db.Query(`SELECT mid_rate FROM rates
+		WHERE currency = ? AND date > ? AND mid_rate IS NOT NULL ORDER BY date`, curr, since)
+	if err != nil {
+		return nil, fmt.Errorf("could not query %s mid-rates: %w", curr, err)
+	}
+	defer rows.Close()
+	var rates []float64
+	for rows.Next() {
+		var rate float64
+		if err := rows.Scan(&rate); err != nil {
+			return nil, fmt.Errorf("could not read %s mid-rates: %w", curr, err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+func TestBackfilledRatesKeepTheirDay(t *testing.T) {
+	setClock(t, "2024-06-07")
+	db, err := openDB(filepath.Join(t.TempDir(), "rates.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	yesterday := clock().AddDate(0, 0, -1)
+	if err := saveRates(db, yesterday, []cbsrates.Rate{{Currency: "USD", MidRate: 14}}); err != nil {
+		t.Fatal(err)
+	}
+	// Archived rates, as -fetch-history stores them, fetched today.
+	for i, mid := range []float64{11, 12, 13} {
+		day := time.Date(2024, time.January, 1+i, 0, 0, 0, 0, time.Local)
+		if err := saveRatesOn(db, day, clock(), []cbsrates.Rate{{Currency: "USD", MidRate: mid}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prev, err := previousRate(db, "USD", clock())
+	if err != nil {
+		t.Fatalf("previousRate: %v", err)
+	}
+	if prev.MidRate != 14 {
+		t.Errorf("previous USD mid-rate = %v, want 14, that of yesterday", prev.MidRate)
+	}
+
+	var buf bytes.Buffer
+	if err := printHistory(&buf, db, []string{"USD"}, 7); err != nil {
+		t.Fatalf("printHistory: %v", err)
+	}
+	history := buf.String()
+	if strings.Contains(history, "2024-01-0") {
+		t.Errorf("the last 7 days list the archived rates:\n%s", history)
+	}
+	if !strings.Contains(history, yesterday.Format("2006-01-02 15:04")) {
+		t.Errorf("the last 7 days do not list yesterday's rates:\n%s", history)
+	}
+
+	buf.Reset()
+	if err := printHistory(&buf, db, []string{"USD"}, 365); err != nil {
+		t.Fatalf("printHistory: %v", err)
+	}
+	if first := strings.Split(buf.String(), "\n")[1]; !strings.HasPrefix(first, "2024-01-01   ") {
+		t.Errorf("the first archived rates are listed as %q, want by their day alone", first)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/diff"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/rates"
+)
+
+// parseDiffDates: parses the -diff option, two YYYY-MM-DD dates separated by a
+// comma.
+func parseDiffDates(text string) ([2]time.Time, error) {
+	var dates [2]time.Time
+	parts := strings.Split(text, ",")
+	if len(parts) != 2 {
+		return dates, fmt.Errorf("%q is not two dates separated by a comma", text)
+	}
+	for i, part := range parts {
+		date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(part), time.Local)
+		if err != nil {
+			return dates, fmt.Errorf("%q is not a YYYY-MM-DD date", part)
+		}
+		dates[i] = date
+	}
+	return dates, nil
+}
+
+// ratesOn: returns the rates of the currencies fetched on date, from db if it
+// is not nil and otherwise from the page -keep-html kept that day.
+func ratesOn(opts options, db *sql.DB, date time.Time) ([]cbsrates.Rate, error) {
+	day := date.Format("2006-01-02")
+	if db == nil {
+		content, err := os.ReadFile(opts.archiveFile(date))
+		if err != nil {
+			return nil, fmt.Errorf("no rates kept for %s, fetch with -keep-html or -db: %w", day, err)
+		}
+		return parseRates(opts.currenciesIn(string(content)), string(content)), nil
+	}
+
+	rows, err := db.Query(`SELECT currency, buying, selling, mid_rate FROM rates WHERE date = ? ORDER BY rowid`, day)
+	if err != nil {
+		return nil, fmt.Errorf("could not query the rates of %s: %w", day, err)
+	}
+	defer rows.Close()
+	stored := make(map[string]cbsrates.Rate)
+	var currencies []string
+	for rows.Next() {
+		var curr string
+		var buying, selling, midRate sql.NullFloat64
+		if err := rows.Scan(&curr, &buying, &selling, &midRate); err != nil {
+			return nil, fmt.Errorf("could not read the rates of %s: %w", day, err)
+		}
+		stored[curr] = cbsrates.Rate{Currency: curr, Buying: buying.Float64, Selling: selling.Float64, MidRate: midRate.Float64}
+		currencies = append(currencies, curr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, fmt.Errorf("no rates stored for %s", day)
+	}
+
+	if !opts.all {
+		currencies = opts.currencies
+	}
+	rs := make([]cbsrates.Rate, 0, len(currencies))
+	for _, curr := range currencies {
+		rate, ok := stored[curr]
+		if !ok {
+			rate = cbsrates.Rate{Currency: curr}
+		}
+		rs = append(rs, rate)
+	}
+	return rs, nil
+}
+
+// formatChange: formats a rate on both days and its change, e.g.
+// 13.45 → 13.72 (+0.2700, +2.01%).
+func formatChange(c diff.Change) string {
+	line := displayRecordRate(c.From) + " → " + displayRecordRate(c.To)
+	if c.From != "" && c.To != "" {
+		line += fmt.Sprintf(" (%+.4f, %+.2f%%)", c.Delta, c.Pct)
+	}
+	return line
+}
+
+// displayRecordRate: returns a record's rate, or N/A if it is empty.
+func displayRecordRate(v string) string {
+	if v == "" {
+		return "N/A"
+	}
+	return v
+}
+
+// printDiff: prints to w how the rates changed from the first -diff date to
+// the second.
+func printDiff(w io.Writer, opts options, db *sql.DB) error {
+	var records [2][]rates.RateRecord
+	for i, date := range opts.diff {
+		rs, err := ratesOn(opts, db, date)
+		if err != nil {
+			return err
+		}
+		records[i] = newRecordList(rs)
+	}
+
+	fmt.Fprintf(w, "Rates from %s to %s\n\n", opts.diff[0].Format("2006-01-02"), opts.diff[1].Format("2006-01-02"))
+	for _, d := range diff.Compare(records[0], records[1]) {
+		fmt.Fprintf(w, "%s Buying:   %s\n", d.Currency, formatChange(d.Buying))
+		fmt.Fprintf(w, "%s Selling:  %s\n", d.Currency, formatChange(d.Selling))
+		fmt.Fprintf(w, "%s Mid-rate: %s\n", d.Currency, formatChange(d.MidRate))
+		fmt.Fprintln(w)
+	}
+	return nil
+}
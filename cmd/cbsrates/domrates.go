@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// domRates: the rates read from the DOM of a fetched page, which do not depend
+// on its HTML being laid out as the regular expressions expect. They are kept
+// in a sidecar file next to the cache, since the cache has no DOM, with the
+// SHA-256 of the HTML they were read from so that they are only used with it.
+type domRates struct {
+	HTML  string          `json:"html_sha256"`
+	Rates []cbsrates.Rate `json:"rates"`
+}
+
+// domRatesFile: returns the path of the DOM rates sidecar for the cache file.
+func domRatesFile(ratesFile string) string {
+	return ratesFile + ".rates.json"
+}
+
+// knownDOMRates: the DOM rates of the page last fetched or read from the
+// cache, if any. The server reads and fetches the rates concurrently.
+var (
+	knownDOMRatesMu sync.Mutex
+	knownDOMRates   domRates
+)
+
+// htmlSum: returns the hex-encoded SHA-256 of ratesHTML.
+func htmlSum(ratesHTML string) string {
+	sum := sha256.Sum256([]byte(ratesHTML))
+	return hex.EncodeToString(sum[:])
+}
+
+// rememberDOMRates: makes rates the DOM rates of ratesHTML, or forgets the
+// known ones if there are none.
+func rememberDOMRates(ratesHTML string, rates []cbsrates.Rate) {
+	knownDOMRatesMu.Lock()
+	defer knownDOMRatesMu.Unlock()
+	knownDOMRates = domRates{HTML: htmlSum(ratesHTML), Rates: rates}
+}
+
+// saveDOMRates: writes the DOM rates of ratesHTML to the cache file's sidecar,
+// or removes it if there are none so that an older one is not kept around.
+func saveDOMRates(ratesFile, ratesHTML string, rates []cbsrates.Rate) error {
+	rememberDOMRates(ratesHTML, rates)
+	if len(rates) == 0 {
+		if err := os.Remove(domRatesFile(ratesFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	out, err := json.Marshal(domRates{HTML: htmlSum(ratesHTML), Rates: rates})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(domRatesFile(ratesFile), out)
+}
+
+// readCache: returns the HTML in the cache file, after loading the DOM rates
+// in its sidecar, if any, so that parseRates and pageRates use them.
+func readCache(ratesFile string) (string, error) {
+	content, err := os.ReadFile(ratesFile)
+	if err != nil {
+		return "", err
+	}
+	ratesHTML := string(content)
+
+	var saved domRates
+	sidecar, err := os.ReadFile(domRatesFile(ratesFile))
+	if err == nil {
+		err = json.Unmarshal(sidecar, &saved)
+	}
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		slog.Debug("could not read the DOM rates, parsing the cache", "err", err)
+	case saved.HTML == htmlSum(ratesHTML):
+		rememberDOMRates(ratesHTML, saved.Rates)
+	}
+	return ratesHTML, nil
+}
+
+// domRatesOf: returns the DOM rates of ratesHTML, dated as the page says, or
+// nil if none are known.
+func domRatesOf(ratesHTML string) []cbsrates.Rate {
+	knownDOMRatesMu.Lock()
+	known := knownDOMRates
+	knownDOMRatesMu.Unlock()
+	if len(known.Rates) == 0 || known.HTML != htmlSum(ratesHTML) {
+		return nil
+	}
+	date, _ := cbsrates.PublishedDate(ratesHTML)
+	rates := make([]cbsrates.Rate, len(known.Rates))
+	for i, rate := range known.Rates {
+		rate.Date = date
+		rates[i] = rate
+	}
+	return rates
+}
+
+// pageRates: returns the rates of every currency in ratesHTML, from its DOM
+// rates if they are known and parsed from the HTML otherwise.
+func pageRates(ratesHTML string) ([]cbsrates.Rate, error) {
+	if rates := domRatesOf(ratesHTML); rates != nil {
+		return rates, nil
+	}
+	return cbsrates.ParseRates(ratesHTML)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+func TestDOMRatesFromCache(t *testing.T) {
+	ratesFile := filepath.Join(t.TempDir(), "cbsrates.html")
+	t.Cleanup(func() { rememberDOMRates("", nil) })
+
+	// The DOM rates differ from what the HTML says, to tell them apart.
+	fromDOM := []cbsrates.Rate{{Currency: "USD", Buying: 13.5, Selling: 14.5, MidRate: 14}}
+	if err := os.WriteFile(ratesFile, []byte(testutil.SampleHTML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveDOMRates(ratesFile, testutil.SampleHTML, fromDOM); err != nil {
+		t.Fatal(err)
+	}
+
+	rememberDOMRates("", nil)
+	ratesHTML, err := readCache(ratesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates := parseRates([]string{"USD", "EUR"}, ratesHTML)
+	if rates[0].Buying != 13.5 || rates[0].Date.IsZero() {
+		t.Errorf("USD = %+v, want the DOM rate, dated", rates[0])
+	}
+	if rates[1].Buying != 15.1023 {
+		t.Errorf("EUR = %+v, want the rate parsed from the HTML", rates[1])
+	}
+
+	// A cache written by another run, without DOM rates, is parsed.
+	changed := testutil.SampleHTML + "\n"
+	if err := os.WriteFile(ratesFile, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ratesHTML, err = readCache(ratesFile); err != nil {
+		t.Fatal(err)
+	}
+	if rates := parseRates([]string{"USD"}, ratesHTML); rates[0].Buying != 13.9512 {
+		t.Errorf("USD = %+v, want the rate parsed from the HTML", rates[0])
+	}
+
+	// Fetching a page without DOM rates removes the sidecar.
+	if err := saveDOMRates(ratesFile, changed, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(domRatesFile(ratesFile)) {
+		t.Error("the DOM rates sidecar was kept")
+	}
+}
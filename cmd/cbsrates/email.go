@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// emailBody: returns the rates as the text output shows them, without the
+// change since the previous day.
+func emailBody(rs []cbsrates.Rate, fetchedAt time.Time, spreadPct bool) string {
+	var published time.Time
+	var body bytes.Buffer
+	for _, rate := range rs {
+		if rate.Buying == 0 && rate.Selling == 0 && rate.MidRate == 0 {
+			continue
+		}
+		published = rate.Date
+		prettyPrint(&body, rate, cbsrates.Rate{}, spreadPct, false, "")
+	}
+	return asOf(published, fetchedAt) + "\n\n" + body.String()
+}
+
+// sendEmail: emails the rates in plain text from opts.smtpFrom to opts.smtpTo
+// through the SMTP server at opts.smtpHost, logging in with opts.smtpUser and
+// opts.smtpPass if they are set. The subject has today's date.
+func sendEmail(opts options, rs []cbsrates.Rate, fetchedAt time.Time) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", opts.smtpFrom)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(opts.smtpTo, ", "))
+	fmt.Fprintf(&msg, "Subject: CBS rates for %s\r\n", clock().Format("2006-01-02"))
+	fmt.Fprintf(&msg, "Date: %s\r\n", clock().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(emailBody(rs, fetchedAt, opts.spreadPct), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if opts.smtpUser != "" {
+		auth = smtp.PlainAuth("", opts.smtpUser, opts.smtpPass, opts.smtpHost)
+	}
+	addr := net.JoinHostPort(opts.smtpHost, strconv.Itoa(opts.smtpPort))
+	if err := smtp.SendMail(addr, auth, opts.smtpFrom, opts.smtpTo, []byte(msg.String())); err != nil {
+		return fmt.Errorf("could not email the rates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/rates"
+	"gitlab.com/eoea/cbsrates/ratespb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ratesService: the ratespb.RatesService of the rates s serves.
+type ratesService struct {
+	ratespb.UnimplementedRatesServiceServer
+	s *server
+}
+
+// serveGRPC: starts a gRPC server with the ratespb.RatesService on
+// opts.grpc. So that StreamRates has something to send, the cache is checked
+// every -interval and fetched into when it is out of date, rather than only
+// when the rates are asked for.
+func (s *server) serveGRPC() error {
+	lis, err := net.Listen("tcp", s.opts.grpc)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer()
+	ratespb.RegisterRatesServiceServer(gs, &ratesService{s: s})
+
+	go func() {
+		ticker := time.NewTicker(s.opts.interval)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			if isFetchDay(clock(), s.opts.holidays) && !hasCurrDateRates(s.opts.cacheFile, s.opts.ttl) {
+				s.startFetch()
+			}
+		}
+	}()
+
+	slog.Info("serving rates over gRPC", "addr", s.opts.grpc)
+	return gs.Serve(lis)
+}
+
+// currencies: returns the currencies asked for, or those of -currencies in
+// ratesHTML if none are.
+func (r *ratesService) currencies(asked []string, ratesHTML string) ([]string, error) {
+	if len(asked) == 0 {
+		return r.s.opts.currenciesIn(ratesHTML), nil
+	}
+	currencies, err := parseCurrencies(strings.Join(asked, ","))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return currencies, nil
+}
+
+// records: returns the records of the currencies in ratesHTML as messages.
+func records(currencies []string, ratesHTML string) []*ratespb.RateRecord {
+	var msgs []*ratespb.RateRecord
+	for _, r := range newRecordList(parseRates(currencies, ratesHTML)) {
+		msgs = append(msgs, recordMessage(r))
+	}
+	return msgs
+}
+
+// recordMessage: converts a record to its message.
+func recordMessage(r rates.RateRecord) *ratespb.RateRecord {
+	return &ratespb.RateRecord{
+		Currency:      r.Currency,
+		Buying:        r.Buying,
+		Selling:       r.Selling,
+		MidRate:       r.MidRate,
+		Spread:        r.Spread,
+		EffectiveDate: r.EffectiveDate,
+	}
+}
+
+func (r *ratesService) GetRates(ctx context.Context, req *ratespb.GetRatesRequest) (*ratespb.GetRatesResponse, error) {
+	content, stale, err := r.s.currentHTML()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if stale {
+		grpc.SetHeader(ctx, metadata.Pairs("warning", staleWarning))
+	}
+	currencies, err := r.currencies(req.GetCurrencies(), content)
+	if err != nil {
+		return nil, err
+	}
+	return &ratespb.GetRatesResponse{Rates: records(currencies, content)}, nil
+}
+
+func (r *ratesService) StreamRates(req *ratespb.StreamRatesRequest, stream ratespb.RatesService_StreamRatesServer) error {
+	pages, unsubscribe := r.s.subscribe()
+	defer unsubscribe()
+
+	// The cache is sent first, whatever its age, so that the client does
+	// not wait for the next fetch to get any rates.
+	content, err := readCache(r.s.opts.cacheFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	for ratesHTML := content; ; {
+		if ratesHTML != "" {
+			currencies, err := r.currencies(req.GetCurrencies(), ratesHTML)
+			if err != nil {
+				return err
+			}
+			for _, msg := range records(currencies, ratesHTML) {
+				if err := stream.Send(msg); err != nil {
+					return err
+				}
+			}
+		}
+		select {
+		case ratesHTML = <-pages:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// loadHolidays: reads the public holidays from path, a plain text file with
+// one YYYY-MM-DD date per line. Blank lines and lines starting with # are
+// ignored.
+func loadHolidays(path string) ([]time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open holidays file: %w", err)
+	}
+	defer f.Close()
+
+	var holidays []time.Time
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", line, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date %q", path, n, line)
+		}
+		holidays = append(holidays, day)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read holidays file: %w", err)
+	}
+	return holidays, nil
+}
+
+// fixedHolidays: the Seychelles public holidays that fall on the same date
+// every year, as month and day.
+var fixedHolidays = [][2]int{
+	{1, 1},   // New Year's Day
+	{1, 2},   // New Year holiday
+	{5, 1},   // Labour Day
+	{6, 18},  // National Day
+	{6, 29},  // Independence Day
+	{8, 15},  // Assumption Day
+	{11, 1},  // All Saints' Day
+	{12, 8},  // Immaculate Conception
+	{12, 25}, // Christmas Day
+}
+
+// easter: returns Easter Sunday of year, by the anonymous Gregorian
+// algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+}
+
+// seychellesHolidays: returns the Seychelles public holidays of year. A
+// holiday that falls on a Sunday is observed on the next day that is not
+// already a holiday, e.g. on the 3rd of January when the 1st is a Sunday.
+func seychellesHolidays(year int) []time.Time {
+	var days []time.Time
+	for _, md := range fixedHolidays {
+		days = append(days, time.Date(year, time.Month(md[0]), md[1], 0, 0, 0, 0, time.Local))
+	}
+	sunday := easter(year)
+	days = append(days,
+		sunday.AddDate(0, 0, -2), // Good Friday
+		sunday.AddDate(0, 0, -1), // Easter Saturday
+		sunday.AddDate(0, 0, 1),  // Easter Monday
+		sunday.AddDate(0, 0, 60), // Corpus Christi
+	)
+
+	holidays := slices.Clone(days)
+	for _, day := range days {
+		if day.Weekday() != time.Sunday {
+			continue
+		}
+		observed := day.AddDate(0, 0, 1)
+		for isListed(observed, holidays) {
+			observed = observed.AddDate(0, 0, 1)
+		}
+		holidays = append(holidays, observed)
+	}
+	return holidays
+}
+
+// isListed: reports whether t falls on one of days.
+func isListed(t time.Time, days []time.Time) bool {
+	for _, day := range days {
+		if sameDate(t, day) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHoliday: reports whether t falls on a Seychelles public holiday, or on
+// one of the extra holidays given with -holidays.
+func isHoliday(t time.Time, holidays []time.Time) bool {
+	return isListed(t, seychellesHolidays(t.Year())) || isListed(t, holidays)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"cmp"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// archiveFile: returns the path of the page -keep-html keeps for day, in the
+// -archive-dir if there is one and next to the -cache file otherwise.
+func (opts options) archiveFile(day time.Time) string {
+	dir := cmp.Or(opts.archiveDir, filepath.Dir(opts.cacheFile))
+	archived := filepath.Join(dir, "cbsrates-"+day.Format("2006-01-02")+".html")
+	if opts.period != cbsrates.Daily {
+		archived = periodFile(archived, opts.period)
+	}
+	return archived
+}
+
+// pruneArchive: deletes the pages kept in dir for the days before cutoff. It
+// only logs what it could not delete, as the rates were fetched all the same.
+func pruneArchive(dir string, cutoff time.Time) {
+	kept, err := filepath.Glob(filepath.Join(dir, "cbsrates-*.html"))
+	if err != nil {
+		slog.Warn("could not list the kept rates pages", "err", err)
+		return
+	}
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.Local)
+	for _, path := range kept {
+		// The period, if any, comes after the date: cbsrates-YYYY-MM-DD-weekly.html.
+		name := strings.TrimPrefix(filepath.Base(path), "cbsrates-")
+		if len(name) < len("2006-01-02") {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", name[:len("2006-01-02")], time.Local)
+		if err != nil || !day.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			slog.Warn("could not delete an old rates page", "path", path, "err", err)
+			continue
+		}
+		slog.Debug("deleted an old rates page", "path", path)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneArchive(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]bool{
+		"cbsrates-2024-05-31.html":        false,
+		"cbsrates-2024-05-31-weekly.html": false,
+		"cbsrates-2024-06-01.html":        true,
+		"cbsrates-2024-06-03.html":        true,
+		"cbsrates.html":                   true,
+		"cbsrates-notes.html":             true,
+	}
+	for name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruneArchive(dir, time.Date(2024, time.June, 1, 15, 0, 0, 0, time.Local))
+	for name, kept := range files {
+		if got := fileExists(filepath.Join(dir, name)); got != kept {
+			t.Errorf("%s kept = %v, want %v", name, got, kept)
+		}
+	}
+}
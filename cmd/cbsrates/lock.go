@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errLockTimeout: returned by lockCache when another run held the lock for
+// longer than -lock-timeout.
+var errLockTimeout = errors.New("timed out waiting for another run to fetch the rates")
+
+// lockCache: takes the advisory lock on the file ratesFile.lock, which is held
+// by whichever run is fetching into the cache at ratesFile, waiting up to
+// timeout for another run to let go of it. The returned function lets go of
+// it. The lock file itself is left in place, as removing it would race with
+// the next run taking it.
+func lockCache(ratesFile string, timeout time.Duration) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(ratesFile), 0755); err != nil {
+		return nil, fmt.Errorf("could not create the cache directory: %w", err)
+	}
+	f, err := os.OpenFile(ratesFile+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the cache lock: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not lock the cache: %w", err)
+		}
+		if ok {
+			// Closing the file releases the lock.
+			return func() { f.Close() }, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, errLockTimeout
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
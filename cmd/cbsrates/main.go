@@ -0,0 +1,941 @@
+package main
+
+//
+// This program downloads the rendered fx rates HTML pages from the Central Bank of
+// Seychelles (CBS) site and prints out the rates for SCR in USD, EUR, and GBP.
+//
+// Created by Emile O. E. Antat <eoea754@gmail.com>
+//
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/chart"
+	"gitlab.com/eoea/cbsrates/parser"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"golang.org/x/term"
+)
+
+// knownCurrencies: the ISO 4217 currency codes published on the CBS daily
+// rates page.
+var knownCurrencies = map[string]bool{
+	"AED": true, "AUD": true, "CAD": true, "CHF": true, "CNY": true,
+	"DKK": true, "EUR": true, "GBP": true, "HKD": true, "INR": true,
+	"JPY": true, "KES": true, "MUR": true, "NOK": true, "NZD": true,
+	"SEK": true, "SGD": true, "USD": true, "ZAR": true,
+}
+
+// parseCurrencies: takes a comma-separated list of currency codes and returns
+// them upper-cased in the order given; an error is returned if a code is not
+// one that CBS publishes.
+func parseCurrencies(list string) ([]string, error) {
+	var currencies []string
+	for _, c := range strings.Split(list, ",") {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+		if !knownCurrencies[c] {
+			return nil, fmt.Errorf("unrecognised currency code %q", c)
+		}
+		currencies = append(currencies, c)
+	}
+	if len(currencies) == 0 {
+		return nil, errors.New("no currency codes given")
+	}
+	return currencies, nil
+}
+
+// defaultCacheFile: returns the path set by the CBS_RATES_CACHE (or
+// CBSRATES_CACHE) environment variable, or cbsrates.html in the system
+// temporary directory.
+func defaultCacheFile() string {
+	for _, env := range []string{"CBS_RATES_CACHE", "CBSRATES_CACHE"} {
+		if path := os.Getenv(env); path != "" {
+			return path
+		}
+	}
+	return filepath.Join(os.TempDir(), "cbsrates.html")
+}
+
+// fileExists: reports whether there is a file at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// hasCurrDateRates: takes the file path and returns true if the file was
+// modified less than ttl ago or, when ttl is zero, if the file modification
+// date is the same as the current date; false otherwise.
+func hasCurrDateRates(ratesFile string, ttl time.Duration) bool {
+	fileInfo, err := os.Stat(ratesFile)
+
+	if err != nil {
+		return false
+	}
+
+	if ttl > 0 {
+		return clock().Sub(fileInfo.ModTime()) < ttl
+	}
+
+	return sameDate(fileInfo.ModTime(), clock())
+}
+
+// writeFileAtomic: writes data to a temporary file next to path and renames
+// it to path, so that a run reading path, e.g. an overlapping cron job, never
+// sees it half written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// usableCache: reports whether the cache file at ratesFile holds rates. A run
+// that crashed while writing it can leave it empty or cut short.
+func usableCache(ratesFile string) bool {
+	content, err := readCache(ratesFile)
+	return err == nil && validateRates(content) == nil
+}
+
+// sameDate: reports whether a and b fall on the same calendar date.
+func sameDate(a, b time.Time) bool {
+	a1, a2, a3 := a.Date()
+	b1, b2, b3 := b.Date()
+
+	return a1 == b1 && a2 == b2 && a3 == b3
+}
+
+// parseRates: returns the rate of each currency in ratesHTML, in order, from
+// its DOM rates if they are known; a currency that could not be parsed is
+// returned with no rates.
+func parseRates(currencies []string, ratesHTML string) []cbsrates.Rate {
+	fromDOM := make(map[string]cbsrates.Rate)
+	for _, rate := range domRatesOf(ratesHTML) {
+		fromDOM[rate.Currency] = rate
+	}
+	var rates []cbsrates.Rate
+	for _, curr := range currencies {
+		rate, ok := fromDOM[curr]
+		if !ok {
+			var err error
+			if rate, err = cbsrates.ParseCurrency(curr, ratesHTML); err != nil {
+				rate = cbsrates.Rate{Currency: curr}
+			}
+		}
+		rate.Period = ratePeriod
+		rates = append(rates, rate)
+	}
+	return rates
+}
+
+// deriveMidRates: returns rs with the mid-rates CBS did not publish worked
+// out from the buying and selling rates, for -analytics.
+func deriveMidRates(rs []cbsrates.Rate) []cbsrates.Rate {
+	derived := make([]cbsrates.Rate, len(rs))
+	for i, rate := range rs {
+		rate.MidRate = rate.DerivedMidRate()
+		derived[i] = rate
+	}
+	return derived
+}
+
+// ratePeriod: the -period of the rates in the cache, set before anything is
+// parsed.
+var ratePeriod = cbsrates.Daily
+
+// periodFile: returns path with the name of period before its extension, e.g.
+// cbsrates-weekly.html, for the files of the rates of another period than
+// daily.
+func periodFile(path string, period cbsrates.Period) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + string(period) + ext
+}
+
+// ratePrecision: the number of decimals rates are printed with, set from
+// -precision before anything is printed. A negative precision prints them the
+// way CBS publishes them.
+var ratePrecision = 4
+
+// displayPrecision: the number of decimals of the rates people read, in the
+// text, table and markdown output, set from -round like ratePrecision.
+var displayPrecision = 4
+
+// formatRate: formats a rate with ratePrecision decimals.
+func formatRate(v float64) string {
+	return strconv.FormatFloat(v, 'f', ratePrecision, 64)
+}
+
+// formatDisplayRate: formats a rate with displayPrecision decimals.
+func formatDisplayRate(v float64) string {
+	return strconv.FormatFloat(v, 'f', displayPrecision, 64)
+}
+
+// roundRate: rounds a rate to ratePrecision decimals.
+func roundRate(v float64) float64 {
+	if ratePrecision < 0 {
+		return v
+	}
+	scale := math.Pow10(ratePrecision)
+	return math.Round(v*scale) / scale
+}
+
+// The text output shows a sparkline of the mid-rates of the last sparklineDays
+// once the -db has at least sparklineMinDays of them.
+const (
+	sparklineDays    = 14
+	sparklineMinDays = 7
+)
+
+// trend: returns the sparkline of the recent mid-rates of curr in db, or an
+// empty string if there are too few of them.
+func trend(db *sql.DB, curr string) (string, error) {
+	rates, err := midRates(db, curr, sparklineDays)
+	if err != nil || len(rates) < sparklineMinDays {
+		return "", err
+	}
+	return chart.Sparkline(rates), nil
+}
+
+// displayRate: formats a rate for people to read, showing N/A for a rate that
+// was not published.
+func displayRate(v float64) string {
+	if v == 0 {
+		return "N/A"
+	}
+	return formatDisplayRate(v)
+}
+
+// useColor: whether the text output is coloured, set from -color before
+// anything is printed.
+var useColor = false
+
+// ANSI colours of the text output.
+const (
+	green = "\x1b[32m"
+	red   = "\x1b[31m"
+)
+
+// colorize: returns s in the ANSI colour, or as it is without useColor.
+func colorize(color, s string) string {
+	if !useColor {
+		return s
+	}
+	return color + s + "\x1b[0m"
+}
+
+// formatDelta: returns the change from prev to v, with displayPrecision
+// decimals like the rates, and pct, the same change in percent, with an arrow,
+// coloured green when the rate went up and red when it went down, or an empty
+// string if either rate is missing.
+func formatDelta(v, prev, pct float64) string {
+	if v == 0 || prev == 0 {
+		return ""
+	}
+	delta := v - prev
+	// Rounded as Spread is, for when the rates are shown as CBS publishes
+	// them.
+	text := formatDisplayRate(math.Round(delta*1e6) / 1e6)
+	if delta >= 0 {
+		text = "+" + text
+	}
+	switch {
+	case delta > 0:
+		return " " + colorize(green, fmt.Sprintf("(%s, %+.2f%% ▲)", text, pct))
+	case delta < 0:
+		return " " + colorize(red, fmt.Sprintf("(%s, %+.2f%% ▼)", text, pct))
+	}
+	return fmt.Sprintf(" (%s, %+.2f%%)", text, pct)
+}
+
+// prettyPrint: prints out the information on the rates that I need in a
+// convenient layout to w. When prev holds the previous day's rates the change
+// since then is shown next to each rate. The spread is left out when it
+// cannot be worked out; with spreadPct it is also shown as a percentage of the
+// mid-rate. With useColor the buying rate is green and the selling one red.
+// With invert the rates are also shown in units of the currency per SCR. A
+// trend line is added with the sparkline of the mid-rates, if not empty.
+func prettyPrint(w io.Writer, rate cbsrates.Rate, prev cbsrates.Rate, spreadPct, invert bool, sparkline string) {
+	fmt.Fprintln(w, "Currency:", rate.Currency)
+	record, prevRecord := parser.Record(rate), parser.Record(prev)
+	fmt.Fprintln(w, "Buying:  ", colorize(green, displayRate(rate.Buying))+formatDelta(rate.Buying, prev.Buying, record.ChangePctOf(prevRecord, "buying")))
+	fmt.Fprintln(w, "Selling: ", colorize(red, displayRate(rate.Selling))+formatDelta(rate.Selling, prev.Selling, record.ChangePctOf(prevRecord, "selling")))
+	fmt.Fprintln(w, "Mid-rate:", displayRate(rate.MidRate)+formatDelta(rate.MidRate, prev.MidRate, record.ChangePct(prevRecord)))
+	if spread := rate.Spread(); spread != 0 {
+		line := formatDisplayRate(spread)
+		if pct := rate.SpreadPct(); spreadPct && pct != 0 {
+			line += fmt.Sprintf(" (%.2f%%)", pct)
+		}
+		fmt.Fprintln(w, "Spread:  ", line)
+	}
+	if invert {
+		fmt.Fprintf(w, "Per SCR:  buying %s, selling %s, mid-rate %s %s\n",
+			displayInverse(rate.Buying), displayInverse(rate.Selling), displayInverse(rate.MidRate), rate.Currency)
+	}
+	if sparkline != "" {
+		fmt.Fprintln(w, "Trend:   ", sparkline)
+	}
+	fmt.Fprintln(w)
+}
+
+// asOf: returns the line that dates the text output with published, the day
+// CBS published the rates for. A stale cache must not pass for today's rates,
+// so cacheDate, the day they were fetched, stands in for it when the page
+// does not say.
+func asOf(published, cacheDate time.Time) string {
+	title := "Rates"
+	switch ratePeriod {
+	case cbsrates.Weekly:
+		title = "Weekly average rates"
+	case cbsrates.Monthly:
+		title = "Monthly average rates"
+	}
+	if published.IsZero() {
+		return fmt.Sprintf("%s as of %s (cache date)", title, cacheDate.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s as of %s", title, published.Format("2006-01-02"))
+}
+
+// asOf: returns the asOf line of the text output, which with -dry-run says
+// that the rates come from the cache.
+func (opts options) asOf(published, cacheDate time.Time) string {
+	if opts.dryRun {
+		return asOf(published, cacheDate) + " (cached)"
+	}
+	return asOf(published, cacheDate)
+}
+
+// errDryRun: returned by cachedRates when -dry-run has no cache to show.
+var errDryRun = errors.New("no cached rates to show")
+
+// printDryRun: prints to stderr what fetchToCache would do, for -dry-run.
+func printDryRun(opts options) {
+	fmt.Fprintln(os.Stderr, "Would fetch from", opts.url)
+	fmt.Fprintln(os.Stderr, "Would write cache to", opts.cacheFile)
+	if opts.keepHTML {
+		fmt.Fprintln(os.Stderr, "Would keep a copy of the page as", opts.archiveFile(clock()))
+	}
+	if opts.dbFile != "" {
+		fmt.Fprintln(os.Stderr, "Would store the rates in", opts.dbFile)
+	}
+	if opts.webhook != "" || opts.slackWebhook != "" || len(opts.smtpTo) > 0 {
+		fmt.Fprintln(os.Stderr, "Would send the rates to the webhooks and -smtp-to")
+	}
+}
+
+// isFetchDay: reports whether CBS publishes new rates on the day of t.
+//
+// CBS does not seem to update their rates on Saturdays and Sundays, so the
+// request times out if we run this on those days; this is the fix to ignore
+// downloads on Saturdays and Sundays. CBS does not update on public holidays
+// either: the Seychelles ones are built in and -holidays adds any others.
+func isFetchDay(t time.Time, holidays []time.Time) bool {
+	day := t.Weekday()
+	return day != time.Saturday && day != time.Sunday && !isHoliday(t, holidays)
+}
+
+// clock: returns the current time that whether to fetch, the freshness of
+// the cache and the dates of the rates are worked out from. It is a variable,
+// time.Now by default, so that the weekend and holiday logic can be run as on
+// any day.
+var clock = time.Now
+
+// maxRateAge: the number of days CBS publishes rates on that can go by before
+// the rates shown are warned about as old.
+const maxRateAge = 3
+
+// fetchDaysSince: returns how many days CBS publishes rates on there have been
+// since published, up to now.
+func fetchDaysSince(published, now time.Time, holidays []time.Time) int {
+	days := 0
+	for d := published.AddDate(0, 0, 1); d.Before(now); d = d.AddDate(0, 0, 1) {
+		if isFetchDay(d, holidays) {
+			days++
+		}
+	}
+	return days
+}
+
+// errFetchFailed: returned by fetchToCache when the rates could not be fetched
+// from CBS, as opposed to failing to cache them.
+var errFetchFailed = errors.New("could not fetch the CBS rates")
+
+// errLayoutChanged: returned by printCurrent when none of the requested
+// currencies could be parsed and neither could any other on the page, which
+// most likely means that CBS changed the layout of the rates page and the
+// parser needs updating.
+var errLayoutChanged = errors.New("no rates could be parsed; the CBS page layout has likely changed")
+
+// exitLayoutChanged: the exit status for errLayoutChanged, so that scripts can
+// tell it apart from other failures.
+const exitLayoutChanged = 3
+
+// validateRates: returns an error unless ratesHTML has rates, see pageRates,
+// and every one of them is a positive, finite number.
+func validateRates(ratesHTML string) error {
+	rates, err := pageRates(ratesHTML)
+	if err != nil {
+		return fmt.Errorf("the fetched page has no rates: %w", err)
+	}
+	for _, rate := range rates {
+		for _, v := range []float64{rate.Buying, rate.Selling, rate.MidRate} {
+			if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("the fetched page has an invalid %s rate %v", rate.Currency, v)
+			}
+		}
+	}
+	return nil
+}
+
+// isRetryable: reports whether a cbsrates.FetchPage error is worth retrying;
+// the page failing to load is, while playwright or the browser not starting is
+// not.
+func isRetryable(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, cbsrates.ErrPageLoad)
+}
+
+// fetchWithRetry: calls cbsrates.FetchPage, retrying up to opts.retries times
+// on a retryable failure. The delay between attempts starts at
+// opts.retryDelay and doubles after each attempt, with up to half of it
+// replaced by jitter so that concurrent runs do not retry in step. Each
+// attempt gets its own opts.timeout. A signal ends the fetch, see runCtx. A
+// page without rates is saved as a screenshot next to the cache, as
+// cbsrates-error-YYYYMMDD-HHMMSS.png, to see what CBS changed.
+func fetchWithRetry(opts options) (string, []cbsrates.Rate, error) {
+	fetching.Add(1)
+	defer fetching.Done()
+	delay := opts.retryDelay
+	for attempt := 1; ; attempt++ {
+		ctx := runCtx
+		cancel := context.CancelFunc(func() {})
+		if opts.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		}
+		slog.Debug("fetching rates", "attempt", attempt, "browser", opts.browser)
+		fetchOpts := opts.fetchOptions()
+		fetchOpts.ErrorScreenshot = filepath.Join(filepath.Dir(opts.cacheFile), "cbsrates-error-"+clock().Format("20060102-150405")+".png")
+		ratesHTML, rates, err := cbsrates.FetchPage(ctx, fetchOpts)
+		cancel()
+		if err == nil {
+			// The screenshot is only taken of a page without rates,
+			// which validateRates then turns down.
+			if fileExists(fetchOpts.ErrorScreenshot) {
+				slog.Error("no rates found on the fetched page, saved a screenshot of it", "screenshot", fetchOpts.ErrorScreenshot)
+			}
+			return ratesHTML, rates, nil
+		}
+		slog.Debug("fetch attempt failed", "attempt", attempt, "err", err)
+		if attempt > opts.retries || !isRetryable(err) {
+			return "", nil, err
+		}
+
+		wait := delay
+		if half := delay / 2; half > 0 {
+			wait = half + rand.N(half)
+		}
+		slog.Warn("fetch failed, retrying", "attempt", attempt, "delay", wait, "err", err)
+		select {
+		case <-time.After(wait):
+		case <-runCtx.Done():
+			return "", nil, runCtx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// fetchToCache: fetches the rates from CBS and writes them to the cache file,
+// with the rates read from the page's DOM in its sidecar, see domRates, and
+// to a copy named after the day next to it with -keep-html. If db
+// is not nil it also stores them in the history database. The rates are then
+// sent to the webhooks, if any. It returns the fetched HTML.
+func fetchToCache(opts options, db *sql.DB) (string, error) {
+	ratesFile := opts.cacheFile
+	ratesHTML, rates, err := fetchWithRetry(opts)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errFetchFailed, err)
+	}
+	rememberDOMRates(ratesHTML, rates)
+	// A partial or error page must not replace a good cache, so it is
+	// treated as a failed fetch.
+	if err := validateRates(ratesHTML); err != nil {
+		return "", fmt.Errorf("%w: %w", errFetchFailed, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ratesFile), 0755); err != nil {
+		return "", fmt.Errorf("could not create the cache directory: %w", err)
+	}
+	if err := savePrevious(ratesFile); err != nil {
+		slog.Warn("could not keep the previous rates", "err", err)
+	}
+	if err := writeFileAtomic(ratesFile, []byte(ratesHTML)); err != nil {
+		return "", fmt.Errorf("failed to write to the cache file: %w", err)
+	}
+	if err := saveDOMRates(ratesFile, ratesHTML, rates); err != nil {
+		slog.Warn("could not keep the rates read from the page", "err", err)
+	}
+	fetchedAt := clock()
+	if opts.keepHTML {
+		archived := opts.archiveFile(fetchedAt)
+		err := os.MkdirAll(filepath.Dir(archived), 0755)
+		if err == nil {
+			err = os.WriteFile(archived, []byte(ratesHTML), 0644)
+		}
+		if err != nil {
+			slog.Warn("could not keep a copy of the rates page", "err", err)
+		}
+		if opts.retain > 0 {
+			pruneArchive(filepath.Dir(archived), fetchedAt.AddDate(0, 0, -opts.retain))
+		}
+	}
+	if db != nil {
+		if err := saveRates(db, fetchedAt, parseRates(cbsrates.Currencies(ratesHTML), ratesHTML)); err != nil {
+			return "", err
+		}
+	}
+	notify(opts, parseRates(opts.currenciesIn(ratesHTML), ratesHTML), fetchedAt)
+	return ratesHTML, nil
+}
+
+// run: opens the -db database, if any, and does what the options ask: print
+// or export the history, serve the rates, watch them or print the current
+// ones.
+func run(opts options) error {
+	// A dry run writes nothing, so neither is the database opened, which
+	// would create it.
+	var db *sql.DB
+	if opts.dbFile != "" && !opts.dryRun {
+		var err error
+		if db, err = openDB(opts.dbFile); err != nil {
+			return err
+		}
+		defer db.Close()
+	}
+
+	if !opts.diff[0].IsZero() {
+		out, closeOut, err := createOutput(opts.output)
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+		if err := printDiff(out, opts, db); err != nil {
+			return err
+		}
+		return closeOut()
+	}
+
+	if !opts.fetchHistory[0].IsZero() {
+		return fetchHistory(opts, db)
+	}
+
+	if opts.exportXLSX != "" {
+		if db == nil {
+			return errors.New("-export-xlsx needs a database set with -db")
+		}
+		return exportXLSX(opts.exportXLSX, db)
+	}
+
+	if opts.history > 0 {
+		if db == nil {
+			return errors.New("-history needs a database set with -db")
+		}
+		out, closeOut, err := createOutput(opts.output)
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+		if err := printHistory(out, db, opts.currencies, opts.history); err != nil {
+			return err
+		}
+		return closeOut()
+	}
+
+	if len(opts.sources) > 0 {
+		out, closeOut, err := createOutput(opts.output)
+		if err != nil {
+			return err
+		}
+		defer closeOut()
+		if err := printSources(out, opts, db); err != nil {
+			return err
+		}
+		return closeOut()
+	}
+
+	if opts.serve != "" || opts.grpc != "" {
+		return serve(opts, db)
+	}
+	if opts.watch {
+		return watch(opts, db)
+	}
+	return printCurrent(opts, db, opts.noCache)
+}
+
+// readRatesFile: reads a saved rates page from path, the -file option, or
+// from stdin if path is -. The page is dated with when the file was last
+// written, or with now for stdin.
+func readRatesFile(path string) (string, time.Time, error) {
+	if path == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("could not read the rates from stdin: %w", err)
+		}
+		return string(content), clock(), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not read the rates file: %w", err)
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not stat the rates file: %w", err)
+	}
+	return string(content), fileInfo.ModTime(), nil
+}
+
+// lockedFetch: runs fetchToCache while holding the cache lock, so that runs
+// that overlap, e.g. cron jobs and -watch, do not all fetch. If another run
+// fetched the rates while this one waited for the lock, their cache is used
+// unless refetch is set, and the returned HTML is empty.
+func lockedFetch(opts options, db *sql.DB, refetch bool) (string, error) {
+	unlock, err := lockCache(opts.cacheFile, opts.lockTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if !refetch && hasCurrDateRates(opts.cacheFile, opts.ttl) && usableCache(opts.cacheFile) {
+		slog.Debug("another run fetched the rates", "cache", opts.cacheFile)
+		return "", nil
+	}
+	content, err := fetchToCache(opts, db)
+	if err == nil {
+		slog.Info("fetched the rates", "url", opts.url)
+	}
+	return content, err
+}
+
+// cachedRates: makes sure the cache file holds the current rates, fetching
+// them from CBS if needed or if refetch is set, and returns them with the day
+// they were fetched and whether that was just now. Nothing is fetched on
+// weekends and public holidays.
+func cachedRates(opts options, db *sql.DB, refetch bool) (ratesHTML string, ratesDate time.Time, fetched bool, err error) {
+	ratesFile := opts.cacheFile
+
+	if isHoliday(clock(), opts.holidays) {
+		slog.Info("today is a public holiday, using the cached rates")
+	}
+	fetchDay := isFetchDay(clock(), opts.holidays)
+	fresh := hasCurrDateRates(ratesFile, opts.ttl)
+	// An empty or broken cache is no use even when CBS publishes nothing
+	// new, so it is always a miss.
+	usable := usableCache(ratesFile)
+	switch {
+	case fileExists(ratesFile) && !usable:
+		slog.Warn("the cache has no rates, fetching them again", "cache", ratesFile)
+		fetchDay, fresh = true, false
+	case !fetchDay:
+		slog.Debug("CBS does not publish rates today, using the cache", "cache", ratesFile)
+	case fresh && !refetch:
+		slog.Debug("cache hit", "cache", ratesFile)
+	default:
+		slog.Debug("cache miss, fetching the rates", "cache", ratesFile, "fresh", fresh)
+	}
+	if fetchDay && (refetch || !fresh) && opts.dryRun {
+		printDryRun(opts)
+		if !usable {
+			fmt.Fprintln(os.Stderr, "No cached rates to show at", ratesFile)
+			return "", time.Time{}, false, errDryRun
+		}
+	} else if fetchDay && (refetch || !fresh) {
+		content, err := lockedFetch(opts, db, refetch)
+		switch {
+		case err == nil:
+			ratesHTML = content
+			fetched = content != ""
+		case errors.Is(err, errLockTimeout) && usable:
+			slog.Warn("using the cached rates", "err", err)
+		case errors.Is(err, errFetchFailed) && usable:
+			slog.Warn("using the cached rates", "err", err)
+		default:
+			return "", time.Time{}, false, err
+		}
+	}
+
+	if len(ratesHTML) == 0 {
+		content, err := readCache(ratesFile)
+		if err != nil {
+			return "", time.Time{}, false, fmt.Errorf("could not read an old rates file: %w", err)
+		}
+		ratesHTML = content
+	}
+
+	fileInfo, err := os.Stat(ratesFile)
+	if err != nil {
+		return "", time.Time{}, false, fmt.Errorf("could not stat the rates file: %w", err)
+	}
+	return ratesHTML, fileInfo.ModTime(), fetched, nil
+}
+
+// printCurrent: prints the rates for the requested currencies, from the cache
+// as kept by cachedRates or from the -file page, which is neither fetched nor
+// cached.
+func printCurrent(opts options, db *sql.DB, refetch bool) error {
+	ratesFile := opts.cacheFile
+	var ratesHTML string
+	var ratesDate time.Time
+	fetched := false
+	var err error
+	if opts.file != "" {
+		ratesFile = opts.file
+		ratesHTML, ratesDate, err = readRatesFile(opts.file)
+	} else {
+		ratesHTML, ratesDate, fetched, err = cachedRates(opts, db, refetch)
+	}
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// A saved page is read for the day it has, however old.
+	if published, err := cbsrates.PublishedDate(ratesHTML); err == nil && opts.file == "" {
+		if days := fetchDaysSince(published, clock(), opts.holidays); days > maxRateAge {
+			slog.Warn("the rates are more than 3 business days old", "date", published.Format("2006-01-02"), "business_days", days)
+		}
+	}
+
+	// The rates are cached by now, so -only-changed only holds back the
+	// output.
+	if opts.onlyChanged && opts.convert == 0 && opts.crossFrom == "" {
+		currencies := opts.currenciesIn(ratesHTML)
+		prev, err := lastRates(db, ratesFile, currencies)
+		if err != nil {
+			return err
+		}
+		if !ratesChanged(parseRates(currencies, ratesHTML), prev, opts.minChange) {
+			slog.Info("the rates have not changed since the last day, printing nothing")
+			return nil
+		}
+	}
+
+	out, closeOut, err := createOutput(opts.output)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+	if (opts.format == "text" || opts.format == "table") && !opts.asJSON {
+		fmt.Fprint(out, opts.heading)
+	}
+
+	if opts.convert != 0 {
+		if err := printConversion(out, opts.convert, opts.from, opts.to, opts.side, ratesHTML); err != nil {
+			return err
+		}
+		return closeOut()
+	}
+	if opts.crossFrom != "" {
+		if err := printCross(out, opts.crossFrom, opts.crossTo, ratesHTML); err != nil {
+			return err
+		}
+		return closeOut()
+	}
+
+	currencies := opts.currenciesIn(ratesHTML)
+
+	parsed := 0
+	for _, rate := range parseRates(currencies, ratesHTML) {
+		if rate.Buying != 0 || rate.Selling != 0 || rate.MidRate != 0 {
+			parsed++
+		}
+	}
+	if _, err := pageRates(ratesHTML); parsed == 0 && err != nil {
+		return fmt.Errorf("%w (requested %s)", errLayoutChanged, strings.Join(currencies, ", "))
+	}
+
+	moved := false
+	if len(opts.alerts) > 0 {
+		all := cbsrates.Currencies(ratesHTML)
+		prev, err := lastRates(db, ratesFile, all)
+		if err != nil {
+			return err
+		}
+		moved = checkAlerts(opts, parseRates(all, ratesHTML), prev, ratesDate, fetched)
+	}
+
+	rs := parseRates(currencies, ratesHTML)
+	if opts.analytics {
+		rs = deriveMidRates(rs)
+	}
+	switch {
+	case opts.asJSON:
+		var prev map[string]cbsrates.Rate
+		if db != nil {
+			if prev, err = lastRates(db, ratesFile, currencies); err != nil {
+				return err
+			}
+		}
+		err = printJSON(out, rs, ratesDate, prev, opts.spreadPct, opts.invert)
+	case opts.format == "json":
+		err = printRecords(out, rs)
+	case opts.format == "yaml":
+		var prev map[string]cbsrates.Rate
+		if db != nil {
+			if prev, err = lastRates(db, ratesFile, currencies); err != nil {
+				return err
+			}
+		}
+		err = printYAML(out, rs, ratesDate, prev, opts.spreadPct, opts.invert)
+	case opts.format == "csv":
+		err = printCSV(out, rs, ratesDate, opts.spreadPct)
+	case opts.format == "table":
+		published, _ := cbsrates.PublishedDate(ratesHTML)
+		err = printTable(out, rs, opts.asOf(published, ratesDate), opts.spreadPct, opts.invert)
+	case opts.format == "markdown":
+		printMarkdown(out, rs, opts.spreadPct)
+	default:
+		// The change since the previous day is only shown on a terminal, so
+		// that piped output stays the same whatever history is kept. It comes
+		// from the database if there is one and from the cache's sidecar file
+		// otherwise.
+		showDelta := opts.output == "" && term.IsTerminal(int(os.Stdout.Fd()))
+		var prev map[string]cbsrates.Rate
+		if showDelta {
+			if prev, err = lastRates(db, ratesFile, currencies); err != nil {
+				return err
+			}
+		}
+
+		published, _ := cbsrates.PublishedDate(ratesHTML)
+		fmt.Fprintf(out, "%s\n\n", opts.asOf(published, ratesDate))
+
+		for _, curr := range currencies {
+			rate, err := cbsrates.ParseCurrency(curr, ratesHTML)
+			// Only rates go to stdout so that it can be parsed; what is
+			// missing is logged. A currency CBS lists without any rate is
+			// still printed, and its part of the page is logged so that it
+			// can be reported.
+			var noRates *cbsrates.NoRatesError
+			switch {
+			case errors.As(err, &noRates):
+				date := published
+				if date.IsZero() {
+					date = ratesDate
+				}
+				slog.Warn("no rates found, please report this with the page snippet",
+					"currency", curr, "date", date.Format("2006-01-02"), "html", noRates.Section)
+				rate = cbsrates.Rate{Currency: curr}
+			case errors.Is(err, cbsrates.ErrNotListed):
+				slog.Warn("no rates found", "err", err)
+				continue
+			case err != nil:
+				slog.Warn("no rates found", "currency", curr, "err", err)
+				continue
+			}
+			if opts.analytics {
+				rate.MidRate = rate.DerivedMidRate()
+			}
+			var sparkline string
+			if showDelta && db != nil {
+				if sparkline, err = trend(db, curr); err != nil {
+					return err
+				}
+			}
+			prettyPrint(out, rate, prev[curr], opts.spreadPct, opts.invert, sparkline)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not print rates: %w", err)
+	}
+	if err := closeOut(); err != nil {
+		return err
+	}
+	if moved {
+		return errRateMoved
+	}
+	return nil
+}
+
+// newLogger: returns a logger writing to stderr at the given level (debug,
+// info, warn or error) in the given format (text or json).
+func newLogger(level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q: must be debug, info, warn or error", level)
+	}
+	handlerOpts := &slog.HandlerOptions{Level: l}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, handlerOpts)), nil
+	}
+	return nil, fmt.Errorf("invalid -log-format %q: must be text or json", format)
+}
+
+func main() {
+	opts, err := parseFlags()
+	if errors.Is(err, errDone) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if opts.version {
+		printVersion(os.Stdout, readBuildInfo())
+		return
+	}
+
+	level := opts.logLevel
+	if opts.quiet {
+		level = "error"
+	}
+	logger, err := newLogger(level, opts.logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+	slog.Debug("build info", readBuildInfo().logAttrs()...)
+	// -watch stops on a signal by itself, between readings.
+	handleSignals(!opts.watch)
+	ratePrecision = opts.precision
+	displayPrecision = opts.round
+	useColor = opts.useColor()
+	ratePeriod = opts.period
+
+	// All errors end up here so that the program only exits in one place.
+	if err := run(opts); err != nil {
+		slog.Error(err.Error())
+		if errors.Is(err, errLayoutChanged) {
+			os.Exit(exitLayoutChanged)
+		}
+		if errors.Is(err, errRateMoved) {
+			os.Exit(exitRateMoved)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+func TestFetchToCacheMockServer(t *testing.T) {
+	testutil.SkipWithoutPlaywright(t)
+	url, cleanup := testutil.MockCBSServer(t, testutil.SampleHTML)
+	defer cleanup()
+
+	opts := options{
+		url:        url,
+		currencies: []string{"USD"},
+		cacheFile:  filepath.Join(t.TempDir(), "cbsrates.html"),
+		timeout:    time.Minute,
+	}
+	if _, err := fetchToCache(opts, nil); err != nil {
+		t.Fatalf("fetchToCache: %v", err)
+	}
+	content, err := os.ReadFile(opts.cacheFile)
+	if err != nil {
+		t.Fatalf("no cache written: %v", err)
+	}
+	rate, err := cbsrates.ParseCurrency("USD", string(content))
+	if err != nil {
+		t.Fatalf("ParseCurrency on the cache: %v", err)
+	}
+	if rate.Buying != 13.9512 || rate.Selling != 14.52 || rate.MidRate != 14.2356 {
+		t.Errorf("cached USD rates = %+v, want 13.9512, 14.52 and 14.2356", rate)
+	}
+}
+
+func TestPrettyPrint(t *testing.T) {
+	tests := []struct {
+		curr string
+		want string
+	}{
+		{"USD", "Currency: USD\nBuying:   13.9512\nSelling:  14.5200\nMid-rate: 14.2356\nSpread:   0.5688\n\n"},
+		{"EUR", "Currency: EUR\nBuying:   15.1023\nSelling:  15.8800\nMid-rate: 15.4911\nSpread:   0.7777\n\n"},
+		// Without the selling rate there is no spread either.
+		{"GBP", "Currency: GBP\nBuying:   17.6500\nSelling:  N/A\nMid-rate: N/A\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.curr, func(t *testing.T) {
+			rate := parseRates([]string{tt.curr}, testutil.SampleHTML)[0]
+			var buf bytes.Buffer
+			prettyPrint(&buf, rate, cbsrates.Rate{}, false, false, "")
+			if got := buf.String(); got != tt.want {
+				t.Errorf("prettyPrint(%s) =\n%s\nwant\n%s", tt.curr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsableCache(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"rates", testutil.SampleHTML, true},
+		// A run that crashed while writing the cache can leave it empty.
+		{"zero bytes", "", false},
+		{"error page", "<html><body>Service Unavailable</body></html>", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".html")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := usableCache(path); got != tt.want {
+				t.Errorf("usableCache = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if usableCache(filepath.Join(dir, "missing.html")) {
+		t.Error("usableCache of a missing file = true, want false")
+	}
+}
+
+func TestIsFetchDay(t *testing.T) {
+	tests := []struct {
+		day  string
+		want bool
+	}{
+		{"2024-06-03", true},  // Monday
+		{"2024-06-07", true},  // Friday
+		{"2024-06-08", false}, // Saturday
+		{"2024-06-09", false}, // Sunday
+		{"2024-06-18", false}, // National Day
+		{"2024-03-29", false}, // Good Friday
+		{"2023-01-03", false}, // New Year's Day, from Sunday the 1st
+	}
+	for _, tt := range tests {
+		day, _ := time.ParseInLocation("2006-01-02", tt.day, time.Local)
+		if got := isFetchDay(day.Add(9*time.Hour), nil); got != tt.want {
+			t.Errorf("isFetchDay(%s) = %v, want %v", tt.day, got, tt.want)
+		}
+	}
+}
+
+// setClock: makes clock return day, a YYYY-MM-DD date, at 9 in the morning
+// for the rest of the test.
+func setClock(t *testing.T, day string) {
+	t.Helper()
+	d, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock = func() time.Time { return d.Add(9 * time.Hour) }
+	t.Cleanup(func() { clock = time.Now })
+}
+
+func TestCachedRatesWeekend(t *testing.T) {
+	url, cleanup := testutil.MockCBSServer(t, testutil.SampleHTML)
+	defer cleanup()
+	tests := []struct {
+		day     string
+		fetches bool
+	}{
+		{"2024-06-07", true},  // Friday
+		{"2024-06-08", false}, // Saturday
+		{"2024-06-09", false}, // Sunday
+	}
+	for _, tt := range tests {
+		t.Run(tt.day, func(t *testing.T) {
+			setClock(t, tt.day)
+			opts := options{
+				url:         url,
+				currencies:  []string{"USD"},
+				cacheFile:   filepath.Join(t.TempDir(), "cbsrates.html"),
+				timeout:     time.Minute,
+				lockTimeout: time.Second,
+			}
+			// Fetched a week before, so the cache is out of date.
+			if err := os.WriteFile(opts.cacheFile, []byte(testutil.SampleHTML), 0644); err != nil {
+				t.Fatal(err)
+			}
+			stale := clock().AddDate(0, 0, -7)
+			if err := os.Chtimes(opts.cacheFile, stale, stale); err != nil {
+				t.Fatal(err)
+			}
+
+			ratesHTML, _, _, err := cachedRates(opts, nil, false)
+			if err != nil {
+				t.Fatalf("cachedRates: %v", err)
+			}
+			if ratesHTML == "" {
+				t.Error("cachedRates returned no rates")
+			}
+			// Only a fetch takes the cache lock.
+			if fetched := fileExists(opts.cacheFile + ".lock"); fetched != tt.fetches {
+				t.Errorf("fetched = %v, want %v", fetched, tt.fetches)
+			}
+		})
+	}
+}
+
+func TestFormatDeltaPrecision(t *testing.T) {
+	defer func(p int) { displayPrecision = p }(displayPrecision)
+	tests := []struct {
+		precision int
+		v, prev   float64
+		want      string
+	}{
+		{4, 14.52, 14.2356, " (+0.2844, +2.00% ▲)"},
+		{2, 14.52, 14.2356, " (+0.28, +2.00% ▲)"},
+		{-1, 14.52, 13.9512, " (+0.5688, +4.08% ▲)"},
+		{2, 13.9512, 14.52, " (-0.57, -3.92% ▼)"},
+		{2, 14.52, 14.52, " (+0.00, +0.00%)"},
+	}
+	for _, tt := range tests {
+		displayPrecision = tt.precision
+		if got := formatDelta(tt.v, tt.prev, (tt.v-tt.prev)/tt.prev*100); got != tt.want {
+			t.Errorf("formatDelta(%v, %v) with precision %d = %q, want %q", tt.v, tt.prev, tt.precision, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.com/eoea/cbsrates/parser"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// Descriptions of the metrics served on /metrics.
+var (
+	buyingDesc    = prometheus.NewDesc("cbsrates_buying", "CBS buying rate of the currency in SCR.", []string{"currency"}, nil)
+	sellingDesc   = prometheus.NewDesc("cbsrates_selling", "CBS selling rate of the currency in SCR.", []string{"currency"}, nil)
+	midRateDesc   = prometheus.NewDesc("cbsrates_mid_rate", "CBS mid-rate of the currency in SCR.", []string{"currency"}, nil)
+	changePctDesc = prometheus.NewDesc("cbsrates_change_pct", "Change of the CBS rate since the last day, in percent.", []string{"currency", "rate_type"}, nil)
+	lastFetchDesc = prometheus.NewDesc("cbsrates_last_fetch_timestamp_seconds", "Unix time the rates were last fetched from CBS.", nil, nil)
+)
+
+// rateMetrics: a prometheus.Collector of the rates of every currency on the
+// last fetched page and, with a -db, of their change since the last day. The
+// rates are replaced all at once so that a scrape never mixes two fetches.
+type rateMetrics struct {
+	mu        sync.Mutex
+	rates     []cbsrates.Rate
+	prev      map[string]cbsrates.Rate
+	fetchedAt time.Time
+}
+
+// update: replaces the rates with those fetched at fetchedAt, and prev, the
+// rates of the last day keyed by currency, which may be nil.
+func (m *rateMetrics) update(rates []cbsrates.Rate, prev map[string]cbsrates.Rate, fetchedAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rates = rates
+	m.prev = prev
+	m.fetchedAt = fetchedAt
+}
+
+func (m *rateMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- buyingDesc
+	ch <- sellingDesc
+	ch <- midRateDesc
+	ch <- changePctDesc
+	ch <- lastFetchDesc
+}
+
+// Collect: sends the rates and their change, leaving out those CBS did not
+// publish, and the time they were fetched, if they ever were.
+func (m *rateMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fetchedAt.IsZero() {
+		return
+	}
+	for _, rate := range m.rates {
+		for desc, v := range map[*prometheus.Desc]float64{buyingDesc: rate.Buying, sellingDesc: rate.Selling, midRateDesc: rate.MidRate} {
+			if v != 0 {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, rate.Currency)
+			}
+		}
+
+		prev, ok := m.prev[rate.Currency]
+		if !ok {
+			continue
+		}
+		record, prevRecord := parser.Record(rate), parser.Record(prev)
+		for rateType, pair := range map[string][2]float64{
+			"buying":   {rate.Buying, prev.Buying},
+			"selling":  {rate.Selling, prev.Selling},
+			"mid_rate": {rate.MidRate, prev.MidRate},
+		} {
+			if pair[0] != 0 && pair[1] != 0 {
+				ch <- prometheus.MustNewConstMetric(changePctDesc, prometheus.GaugeValue, record.ChangePctOf(prevRecord, rateType), rate.Currency, rateType)
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(lastFetchDesc, prometheus.GaugeValue, float64(m.fetchedAt.Unix()))
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/parser"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/rates"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonRate: the JSON representation of a Rate, which -yaml uses too. Rates
+// that CBS did not publish are left as nil so they are emitted as null.
+type jsonRate struct {
+	Currency string   `json:"currency" yaml:"currency"`
+	Buying   *float64 `json:"buying" yaml:"buying"`
+	Selling  *float64 `json:"selling" yaml:"selling"`
+	MidRate  *float64 `json:"mid_rate" yaml:"mid_rate"`
+	Spread   *float64 `json:"spread" yaml:"spread"`
+	// SpreadPct is only there with -spread-pct.
+	SpreadPct *float64 `json:"spread_pct,omitempty" yaml:"spread_pct,omitempty"`
+	Date      string   `json:"date" yaml:"date"`
+	// EffectiveDate is the day CBS published the rates for, which unlike
+	// Date is null if the page does not say.
+	EffectiveDate *string `json:"effective_date" yaml:"effective_date"`
+	// Period is left out for the daily rates, which is all there was before
+	// -period.
+	Period string `json:"period,omitempty" yaml:"period,omitempty"`
+	// ChangePct is the change of the mid-rate since the last day, which is
+	// only known with a -db.
+	ChangePct *float64 `json:"change_pct,omitempty" yaml:"change_pct,omitempty"`
+	// PerSCR is only there with -invert.
+	PerSCR *jsonInverse `json:"per_scr,omitempty" yaml:"per_scr,omitempty"`
+}
+
+// jsonInverse: the rates of a jsonRate quoted the other way round, in units
+// of the currency per SCR, nil where CBS did not publish the rate.
+type jsonInverse struct {
+	Buying  *float64 `json:"buying" yaml:"buying"`
+	Selling *float64 `json:"selling" yaml:"selling"`
+	MidRate *float64 `json:"mid_rate" yaml:"mid_rate"`
+}
+
+// inverse: returns the rate quoted the other way round, 1/v, or zero for a
+// rate that was not published.
+func inverse(v float64) float64 {
+	if v == 0 {
+		return 0
+	}
+	return 1 / v
+}
+
+// inverseDecimals: how many decimals more the inverse rates get, as they are
+// much smaller than the rates.
+const inverseDecimals = 2
+
+// optionalInverse: returns nil for a rate that was not published and a pointer
+// to its inverse otherwise, rounded to inverseDecimals more than the rates.
+func optionalInverse(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	inv := inverse(v)
+	if ratePrecision >= 0 {
+		scale := math.Pow10(ratePrecision + inverseDecimals)
+		inv = math.Round(inv*scale) / scale
+	}
+	return &inv
+}
+
+// displayInverse: formats the inverse of a rate for people to read, showing
+// N/A for a rate that was not published.
+func displayInverse(v float64) string {
+	if v == 0 {
+		return "N/A"
+	}
+	precision := displayPrecision
+	if precision >= 0 {
+		precision += inverseDecimals
+	}
+	return strconv.FormatFloat(inverse(v), 'f', precision, 64)
+}
+
+// optional: returns nil for a rate that was not published and a pointer to the
+// rate otherwise.
+func optional(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	v = roundRate(v)
+	return &v
+}
+
+// rateDate: returns the day CBS published rate for, or date if the page did
+// not say.
+func rateDate(rate cbsrates.Rate, date time.Time) string {
+	if !rate.Date.IsZero() {
+		date = rate.Date
+	}
+	return date.Format("2006-01-02")
+}
+
+// effectiveDate: returns the day CBS published rate for, or nil if it is not
+// known.
+func effectiveDate(rate cbsrates.Rate) *string {
+	if rate.Date.IsZero() {
+		return nil
+	}
+	date := rate.Date.Format("2006-01-02")
+	return &date
+}
+
+// newJSONRates: converts the rates to their JSON representation, dated with
+// their publication date, or date if it is not known. The change of the
+// mid-rate since prev, the rates of the last day keyed by currency, is added
+// where both days have one, and so is the spread as a percentage with
+// spreadPct and the rates in units per SCR with invert.
+func newJSONRates(rates []cbsrates.Rate, date time.Time, prev map[string]cbsrates.Rate, spreadPct, invert bool) []jsonRate {
+	records := make([]jsonRate, 0, len(rates))
+	for _, rate := range rates {
+		var change *float64
+		if p := prev[rate.Currency]; rate.MidRate != 0 && p.MidRate != 0 {
+			pct := parser.Record(rate).ChangePct(parser.Record(p))
+			change = &pct
+		}
+		var spread *float64
+		if pct := rate.SpreadPct(); spreadPct && pct != 0 {
+			pct = math.Round(pct*100) / 100
+			spread = &pct
+		}
+		var perSCR *jsonInverse
+		if invert {
+			perSCR = &jsonInverse{
+				Buying:  optionalInverse(rate.Buying),
+				Selling: optionalInverse(rate.Selling),
+				MidRate: optionalInverse(rate.MidRate),
+			}
+		}
+		records = append(records, jsonRate{
+			PerSCR:        perSCR,
+			ChangePct:     change,
+			Currency:      rate.Currency,
+			Buying:        optional(rate.Buying),
+			Selling:       optional(rate.Selling),
+			MidRate:       optional(rate.MidRate),
+			Spread:        optional(rate.Spread()),
+			SpreadPct:     spread,
+			Date:          rateDate(rate, date),
+			EffectiveDate: effectiveDate(rate),
+			Period:        jsonPeriod(rate.Period),
+		})
+	}
+	return records
+}
+
+// jsonPeriod: returns the JSON period of rates of period, which is empty for
+// the daily ones.
+func jsonPeriod(period cbsrates.Period) string {
+	if period == cbsrates.Daily {
+		return ""
+	}
+	return string(period)
+}
+
+// printJSON: prints the rates to w as a single JSON array, dated as with
+// newJSONRates.
+func printJSON(w io.Writer, rates []cbsrates.Rate, date time.Time, prev map[string]cbsrates.Rate, spreadPct, invert bool) error {
+	out, err := json.Marshal(newJSONRates(rates, date, prev, spreadPct, invert))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(out))
+	return nil
+}
+
+// optionalPct: formats the spread as a percentage of rate's mid-rate, leaving
+// it empty if it cannot be worked out.
+func optionalPct(rate cbsrates.Rate) string {
+	pct := rate.SpreadPct()
+	if pct == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(pct, 'f', 2, 64)
+}
+
+// displayPct: formats the spread as a percentage of rate's mid-rate, or N/A if
+// it cannot be worked out.
+func displayPct(rate cbsrates.Rate) string {
+	if pct := optionalPct(rate); pct != "" {
+		return pct + "%"
+	}
+	return "N/A"
+}
+
+// printYAML: prints the rates to w as a YAML sequence with the same fields as
+// printJSON.
+func printYAML(w io.Writer, rates []cbsrates.Rate, date time.Time, prev map[string]cbsrates.Rate, spreadPct, invert bool) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(newJSONRates(rates, date, prev, spreadPct, invert)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// optionalString: formats a rate, leaving it empty if it was not published.
+func optionalString(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatRate(v)
+}
+
+// newRecord: converts a Rate to its record, with ratePrecision decimals.
+func newRecord(rate cbsrates.Rate) rates.RateRecord {
+	return parser.FormatRecord(rate, ratePrecision)
+}
+
+// newRecordList: converts the rates to records, in order.
+func newRecordList(rs []cbsrates.Rate) []rates.RateRecord {
+	records := make([]rates.RateRecord, 0, len(rs))
+	for _, rate := range rs {
+		records = append(records, newRecord(rate))
+	}
+	return records
+}
+
+// newRecords: converts the rates to records keyed by currency code.
+func newRecords(rs []cbsrates.Rate) rates.Records {
+	records := make(rates.Records, len(rs))
+	for _, rate := range rs {
+		records[rate.Currency] = newRecord(rate)
+	}
+	return records
+}
+
+// printRecords: prints the rates to w as a single JSON object keyed by
+// currency code.
+func printRecords(w io.Writer, rs []cbsrates.Rate) error {
+	out, err := json.Marshal(newRecords(rs))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(out))
+	return nil
+}
+
+// printCSV: prints the rates to w as CSV with a header row, one row per
+// currency, so the output can be appended to a running log. Rates are dated as
+// with newJSONRates. With spreadPct a spread_pct column is added.
+func printCSV(w io.Writer, rs []cbsrates.Rate, date time.Time, spreadPct bool) error {
+	cw := csv.NewWriter(w)
+	header := []string{"date", "currency", "buying", "selling", "mid_rate", "spread"}
+	if spreadPct {
+		header = append(header, "spread_pct")
+	}
+	cw.Write(header)
+	for _, rate := range rs {
+		row := []string{
+			rateDate(rate, date),
+			rate.Currency,
+			optionalString(rate.Buying),
+			optionalString(rate.Selling),
+			optionalString(rate.MidRate),
+			optionalString(rate.Spread()),
+		}
+		if spreadPct {
+			row = append(row, optionalPct(rate))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// printTable: prints the rates to w as a table with a column per rate, so that
+// currencies are easy to compare, after the asOf line. With spreadPct a
+// Spread % column is added, and with invert columns of the rates per SCR.
+func printTable(w io.Writer, rs []cbsrates.Rate, asOf string, spreadPct, invert bool) error {
+	fmt.Fprintf(w, "%s\n\n", asOf)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "Currency\tBuying\tSelling\tMid-rate\tSpread"
+	if spreadPct {
+		header += "\tSpread %"
+	}
+	if invert {
+		header += "\tBuying per SCR\tSelling per SCR\tMid-rate per SCR"
+	}
+	fmt.Fprintln(tw, header)
+	for _, rate := range rs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s", rate.Currency,
+			displayRate(rate.Buying), displayRate(rate.Selling), displayRate(rate.MidRate), displayRate(rate.Spread()))
+		if spreadPct {
+			fmt.Fprintf(tw, "\t%s", displayPct(rate))
+		}
+		if invert {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s", displayInverse(rate.Buying), displayInverse(rate.Selling), displayInverse(rate.MidRate))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// createOutput: creates the file at path, the -o option, for the output, or
+// returns stdout if path is empty. The returned function closes the file; it
+// can be deferred and also called to check the error, as only its first call
+// closes the file.
+func createOutput(path string) (io.Writer, func() error, error) {
+	if path == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create the output file: %w", err)
+	}
+	return f, sync.OnceValue(f.Close), nil
+}
+
+// printMarkdown: prints the rates to w as a GitHub-Flavored Markdown table. The
+// columns are padded, and the rates right-aligned, so that the table also
+// reads well as plain text. With spreadPct a Spread % column is added.
+func printMarkdown(w io.Writer, rs []cbsrates.Rate, spreadPct bool) {
+	header := []string{"Currency", "Buying", "Selling", "Mid-Rate", "Spread"}
+	if spreadPct {
+		header = append(header, "Spread %")
+	}
+	rows := [][]string{header}
+	for _, rate := range rs {
+		row := []string{
+			rate.Currency,
+			displayRate(rate.Buying),
+			displayRate(rate.Selling),
+			displayRate(rate.MidRate),
+			displayRate(rate.Spread()),
+		}
+		if spreadPct {
+			row = append(row, displayPct(rate))
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	for n, row := range rows {
+		line := "|"
+		for i, cell := range row {
+			if i == 0 {
+				line += fmt.Sprintf(" %-*s |", widths[i], cell)
+			} else {
+				line += fmt.Sprintf(" %*s |", widths[i], cell)
+			}
+		}
+		fmt.Fprintln(w, line)
+
+		if n == 0 {
+			sep := "|"
+			for i, w := range widths {
+				if i == 0 {
+					sep += " " + strings.Repeat("-", w) + " |"
+				} else {
+					sep += " " + strings.Repeat("-", w-1) + ": |"
+				}
+			}
+			fmt.Fprintln(w, sep)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// previousRates: the rates of the last day before the current cache, kept in a
+// sidecar file next to the cache so that the change since then can be shown
+// without a database.
+type previousRates struct {
+	Date  string          `json:"date"`
+	Rates []cbsrates.Rate `json:"rates"`
+}
+
+// previousFile: returns the path of the sidecar file for the cache file.
+func previousFile(ratesFile string) string {
+	return ratesFile + ".prev.json"
+}
+
+// savePrevious: copies the rates in the cache file to its sidecar file before
+// the cache is overwritten. Nothing is saved if there is no cache yet or if it
+// was written today, so that the sidecar keeps an earlier day's rates.
+func savePrevious(ratesFile string) error {
+	fileInfo, err := os.Stat(ratesFile)
+	if errors.Is(err, os.ErrNotExist) || hasCurrDateRates(ratesFile, 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := readCache(ratesFile)
+	if err != nil {
+		return err
+	}
+	rates, err := pageRates(content)
+	if err != nil {
+		return err
+	}
+	out, err := json.Marshal(previousRates{
+		Date:  fileInfo.ModTime().Format("2006-01-02"),
+		Rates: rates,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(previousFile(ratesFile), out)
+}
+
+// loadPrevious: returns the rates in the cache file's sidecar keyed by
+// currency, or nil if there are none.
+func loadPrevious(ratesFile string) map[string]cbsrates.Rate {
+	content, err := os.ReadFile(previousFile(ratesFile))
+	if err != nil {
+		return nil
+	}
+	var prev previousRates
+	if err := json.Unmarshal(content, &prev); err != nil {
+		return nil
+	}
+	rates := make(map[string]cbsrates.Rate, len(prev.Rates))
+	for _, rate := range prev.Rates {
+		rates[rate.Currency] = rate
+	}
+	return rates
+}
+
+// lastRates: returns the rates of the currencies on the last day before today,
+// keyed by currency, from db if it is not nil and from the cache's sidecar
+// file otherwise. Currencies without earlier rates are left out.
+func lastRates(db *sql.DB, ratesFile string, currencies []string) (map[string]cbsrates.Rate, error) {
+	if db == nil {
+		return loadPrevious(ratesFile), nil
+	}
+	year, month, day := clock().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	rates := make(map[string]cbsrates.Rate, len(currencies))
+	for _, curr := range currencies {
+		rate, err := previousRate(db, curr, today)
+		if err != nil {
+			return nil, err
+		}
+		if rate.Buying == 0 && rate.Selling == 0 && rate.MidRate == 0 {
+			continue
+		}
+		rates[curr] = rate
+	}
+	return rates, nil
+}
+
+// ratesChanged: reports whether any rate in rs differs from the one in prev by
+// more than minChange, or was published on only one of the two days.
+func ratesChanged(rs []cbsrates.Rate, prev map[string]cbsrates.Rate, minChange float64) bool {
+	for _, rate := range rs {
+		p := prev[rate.Currency]
+		for _, pair := range [][2]float64{{rate.Buying, p.Buying}, {rate.Selling, p.Selling}, {rate.MidRate, p.MidRate}} {
+			if (pair[0] == 0) != (pair[1] == 0) || math.Abs(pair[0]-pair[1]) > minChange {
+				return true
+			}
+		}
+	}
+	return false
+}
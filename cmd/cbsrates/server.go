@@ -0,0 +1,266 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// server: serves the cached rates over HTTP and gRPC and refreshes the cache
+// in the background when it is out of date.
+type server struct {
+	opts    options
+	db      *sql.DB
+	metrics rateMetrics
+
+	mu       sync.Mutex
+	fetching bool
+	// failedAt is when the last fetch failed, or the zero time if it did
+	// not. No fetch is started until -interval after it, so that CBS is not
+	// hammered while it is down.
+	failedAt time.Time
+	// subscribers get the rates page after each fetch, for StreamRates.
+	subscribers map[chan string]bool
+}
+
+// errFetching: returned by currentHTML while out of date rates are being
+// fetched.
+var errFetching = errors.New("rates are being fetched, retry later")
+
+// errBackingOff: returned by currentHTML when the rates are out of date, the
+// last fetch failed less than -interval ago and the cache has no rates to
+// serve in the meantime.
+var errBackingOff = errors.New("could not fetch the rates, retry later")
+
+// staleWarning: the Warning header of the out of date rates served while
+// backing off after a failed fetch.
+const staleWarning = `110 cbsrates "Response is Stale"`
+
+// serve: serves the rates over HTTP on -serve and over gRPC on -grpc, until
+// either fails.
+func serve(opts options, db *sql.DB) error {
+	s := &server{opts: opts, db: db, subscribers: make(map[chan string]bool)}
+
+	// Until the first fetch the metrics come from the cache, dated with
+	// when it was written.
+	if fileInfo, err := os.Stat(opts.cacheFile); err == nil {
+		if content, err := readCache(opts.cacheFile); err == nil {
+			s.updateMetrics(content, fileInfo.ModTime())
+		}
+	}
+
+	errs := make(chan error, 2)
+	if opts.serve != "" {
+		go func() { errs <- s.serveHTTP() }()
+	}
+	if opts.grpc != "" {
+		go func() { errs <- s.serveGRPC() }()
+	}
+	return <-errs
+}
+
+// serveHTTP: starts an HTTP server on opts.serve with the following routes:
+//
+//	GET /rates             the requested currencies, as with -format json
+//	GET /rates/{currency}  a single currency's record
+//	GET /metrics           the rates of every currency, for Prometheus
+func (s *server) serveHTTP() error {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&s.metrics)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rates", s.handleRates)
+	mux.HandleFunc("GET /rates/{currency}", s.handleCurrency)
+	mux.Handle("GET /metrics", s.refreshing(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+
+	slog.Info("serving rates", "addr", s.opts.serve)
+	return http.ListenAndServe(s.opts.serve, mux)
+}
+
+// startFetch: fetches the rates into the cache in the background, unless a
+// fetch is already running or the last one failed less than -interval ago. It
+// reports whether a fetch is running.
+func (s *server) startFetch() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fetching {
+		return true
+	}
+	if !s.failedAt.IsZero() && clock().Before(s.failedAt.Add(s.opts.interval)) {
+		return false
+	}
+	s.fetching = true
+
+	go func() {
+		content, err := lockedFetch(s.opts, s.db, false)
+		if err == nil && content == "" {
+			// Another run fetched the rates into the cache.
+			content, err = readCache(s.opts.cacheFile)
+		}
+		if err != nil {
+			slog.Error("could not fetch rates", "err", err)
+		} else {
+			s.updateMetrics(content, clock())
+		}
+		s.mu.Lock()
+		s.fetching = false
+		if err == nil {
+			s.failedAt = time.Time{}
+			s.publish(content)
+		} else {
+			s.failedAt = clock()
+		}
+		s.mu.Unlock()
+	}()
+	return true
+}
+
+// retryAfter: returns the number of seconds until startFetch will fetch again
+// after a failed fetch, for the Retry-After header.
+func (s *server) retryAfter() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	left := s.failedAt.Add(s.opts.interval).Sub(clock())
+	return strconv.Itoa(max(1, int(math.Ceil(left.Seconds()))))
+}
+
+// publish: hands the fetched rates page to the subscribers, replacing any
+// page they have not taken yet. s.mu must be held.
+func (s *server) publish(ratesHTML string) {
+	for sub := range s.subscribers {
+		select {
+		case <-sub:
+		default:
+		}
+		sub <- ratesHTML
+	}
+}
+
+// subscribe: returns a channel that gets the rates page after each fetch,
+// and the function that stops that.
+func (s *server) subscribe() (<-chan string, func()) {
+	sub := make(chan string, 1)
+	s.mu.Lock()
+	s.subscribers[sub] = true
+	s.mu.Unlock()
+	return sub, func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}
+}
+
+// currentHTML: returns the cached rates page. If the cache is out of date a
+// fetch is started and errFetching is returned instead. If the last fetch
+// failed less than -interval ago, none is started and the out of date cache
+// is returned with stale set, or errBackingOff if it has no rates.
+func (s *server) currentHTML() (content string, stale bool, err error) {
+	if isFetchDay(clock(), s.opts.holidays) && !hasCurrDateRates(s.opts.cacheFile, s.opts.ttl) {
+		if s.startFetch() {
+			return "", false, errFetching
+		}
+		if !usableCache(s.opts.cacheFile) {
+			return "", false, errBackingOff
+		}
+		stale = true
+	}
+	if content, err = readCache(s.opts.cacheFile); err != nil {
+		return "", false, errors.New("no rates available")
+	}
+	return content, stale, nil
+}
+
+// ratesHTML: returns the cached rates page. If the cache is out of date a
+// fetch is started and 202 Accepted is written instead, in which case ok is
+// false. While backing off after a failed fetch the out of date rates are
+// served with a Warning header, or 503 Service Unavailable is written if
+// there are none.
+func (s *server) ratesHTML(w http.ResponseWriter) (content string, ok bool) {
+	content, stale, err := s.currentHTML()
+	switch {
+	case errors.Is(err, errFetching):
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, err.Error(), http.StatusAccepted)
+		return "", false
+	case errors.Is(err, errBackingOff):
+		w.Header().Set("Retry-After", s.retryAfter())
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return "", false
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return "", false
+	}
+	if stale {
+		w.Header().Set("Warning", staleWarning)
+	}
+	return content, true
+}
+
+// updateMetrics: sets the metrics to the rates of every currency in
+// ratesHTML, fetched at fetchedAt, with their change since the last day if
+// there is a database.
+func (s *server) updateMetrics(ratesHTML string, fetchedAt time.Time) {
+	currencies := cbsrates.Currencies(ratesHTML)
+	var prev map[string]cbsrates.Rate
+	if s.db != nil {
+		var err error
+		if prev, err = lastRates(s.db, s.opts.cacheFile, currencies); err != nil {
+			slog.Warn("could not get the last day's rates", "err", err)
+		}
+	}
+	s.metrics.update(parseRates(currencies, ratesHTML), prev, fetchedAt)
+}
+
+// refreshing: wraps h so that a fetch is started in the background when the
+// cache is out of date, while h answers with what there is.
+func (s *server) refreshing(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isFetchDay(clock(), s.opts.holidays) && !hasCurrDateRates(s.opts.cacheFile, s.opts.ttl) {
+			s.startFetch()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// writeJSON: writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("could not write response", "err", err)
+	}
+}
+
+func (s *server) handleRates(w http.ResponseWriter, r *http.Request) {
+	content, ok := s.ratesHTML(w)
+	if !ok {
+		return
+	}
+	writeJSON(w, newRecords(parseRates(s.opts.currenciesIn(content), content)))
+}
+
+func (s *server) handleCurrency(w http.ResponseWriter, r *http.Request) {
+	curr := strings.ToUpper(r.PathValue("currency"))
+
+	content, ok := s.ratesHTML(w)
+	if !ok {
+		return
+	}
+	rate, err := cbsrates.ParseCurrency(curr, content)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, newRecord(rate))
+}
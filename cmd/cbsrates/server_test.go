@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+func TestServerBacksOffAfterFailedFetch(t *testing.T) {
+	setClock(t, "2024-06-07")
+	tests := []struct {
+		name       string
+		cache      string
+		status     int
+		warning    string
+		retryAfter string
+	}{
+		{"stale cache", testutil.SampleHTML, http.StatusOK, staleWarning, ""},
+		{"no cache", "", http.StatusServiceUnavailable, "", "1800"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options{currencies: []string{"USD"}, cacheFile: filepath.Join(t.TempDir(), "cbsrates.html"), interval: time.Hour}
+			if tt.cache != "" {
+				if err := os.WriteFile(opts.cacheFile, []byte(tt.cache), 0644); err != nil {
+					t.Fatal(err)
+				}
+				stale := clock().AddDate(0, 0, -1)
+				if err := os.Chtimes(opts.cacheFile, stale, stale); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &server{opts: opts, failedAt: clock().Add(-30 * time.Minute)}
+
+			rec := httptest.NewRecorder()
+			s.handleRates(rec, httptest.NewRequest("GET", "/rates", nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Warning"); got != tt.warning {
+				t.Errorf("Warning = %q, want %q", got, tt.warning)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+			if s.fetching {
+				t.Error("a fetch was started while backing off")
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// runCtx: done once cbsrates is asked to stop with SIGINT or SIGTERM. The
+// fetches run under it so that their browser is closed rather than left
+// running when the program exits.
+var runCtx = context.Background()
+
+// fetching: the fetches under way, which a signal waits for before exiting.
+var fetching sync.WaitGroup
+
+// exitInterrupted: the exit status after a signal, as a shell reports for
+// SIGINT.
+const exitInterrupted = 130
+
+// signalGrace: how long a signal waits for the fetches under way to close
+// their browser; a second signal exits at once.
+const signalGrace = 10 * time.Second
+
+// handleSignals: sets runCtx up to be done on SIGINT or SIGTERM, after which
+// the program exits once the fetches under way have closed their browser.
+// With exit unset it is left to the caller to stop instead, as -watch does
+// between readings.
+func handleSignals(exit bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	runCtx = ctx
+	go func() {
+		<-ctx.Done()
+		stop()
+		done := make(chan struct{})
+		go func() {
+			fetching.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(signalGrace):
+			slog.Warn("the browser did not close in time")
+		}
+		if exit {
+			os.Exit(exitInterrupted)
+		}
+	}()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gitlab.com/eoea/cbsrates/rates"
+	"gitlab.com/eoea/cbsrates/source"
+)
+
+// sourceNames: the sources -sources can fetch from. Only CBS for now; the
+// banks' pages need a Source of their own each.
+var sourceNames = []string{"cbs"}
+
+// parseSources: parses the comma-separated -sources list, checking each name
+// is one of sourceNames.
+func parseSources(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(sourceNames, name) {
+			return nil, fmt.Errorf("unknown source %q: must be one of %s", name, strings.Join(sourceNames, ", "))
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// cachedCBS: the CBS source as the rest of cbsrates fetches it, through the
+// cache and only on the days CBS publishes rates.
+type cachedCBS struct {
+	opts options
+	db   *sql.DB
+}
+
+func (cachedCBS) Name() string {
+	return "cbs"
+}
+
+// Fetch: returns the rates of -currencies from the cache, fetching them
+// first if it is out of date. The fetch has the -timeout of its own rather
+// than ctx.
+func (c cachedCBS) Fetch(ctx context.Context) ([]rates.RateRecord, error) {
+	ratesHTML, _, _, err := cachedRates(c.opts, c.db, c.opts.noCache)
+	if err != nil {
+		return nil, err
+	}
+	return newRecordList(parseRates(c.opts.currenciesIn(ratesHTML), ratesHTML)), nil
+}
+
+// printSources: fetches the rates of the -sources at the same time and prints
+// them to w grouped by source, as a table each or, with -format json, as an
+// object keyed by source of their records. A source that could not be
+// fetched is logged and left out, unless none could.
+func printSources(w io.Writer, opts options, db *sql.DB) error {
+	var sources []source.Source
+	for _, name := range opts.sources {
+		switch name {
+		case "cbs":
+			sources = append(sources, cachedCBS{opts: opts, db: db})
+		}
+	}
+
+	var fetched []source.Result
+	for _, result := range source.FetchAll(context.Background(), sources) {
+		if result.Err != nil {
+			slog.Warn(result.Err.Error())
+			continue
+		}
+		fetched = append(fetched, result)
+	}
+	if len(fetched) == 0 {
+		return errors.New("could not fetch the rates of any of the -sources")
+	}
+
+	if opts.format == "json" {
+		out := make(map[string]rates.Records, len(fetched))
+		for _, result := range fetched {
+			records := make(rates.Records, len(result.Records))
+			for _, r := range result.Records {
+				records[r.Currency] = r
+			}
+			out[result.Source] = records
+		}
+		return json.NewEncoder(w).Encode(out)
+	}
+
+	for i, result := range fetched {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Source: %s\n\n", strings.ToUpper(result.Source))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Currency\tBuying\tSelling\tMid-rate\tSpread")
+		for _, r := range result.Records {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Currency,
+				cmp.Or(r.Buying, "N/A"), cmp.Or(r.Selling, "N/A"), cmp.Or(r.MidRate, "N/A"), cmp.Or(r.Spread, "N/A"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
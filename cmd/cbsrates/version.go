@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// buildVersion, buildCommit and buildDate: set when packaging with
+//
+//	-ldflags "-X main.buildVersion=v1.2.0 -X main.buildCommit=... -X main.buildDate=..."
+//
+// and then printed by -version instead of what Go embedded in the binary.
+var buildVersion, buildCommit, buildDate string
+
+// buildInfo: what the binary knows about how it was built, for -version and
+// bug reports.
+type buildInfo struct {
+	path, version, goVersion string
+	// revision and time are those of the VCS commit built from, if known.
+	revision, time string
+	modified       bool
+}
+
+// readBuildInfo: returns the build info embedded in the binary, overridden by
+// buildVersion, buildCommit and buildDate where they are set; the fields that
+// are not known are left empty.
+func readBuildInfo() buildInfo {
+	b := buildInfo{version: "unknown"}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		b = buildInfo{
+			path:      info.Main.Path,
+			version:   info.Main.Version,
+			goVersion: info.GoVersion,
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				b.revision = s.Value
+			case "vcs.time":
+				b.time = s.Value
+			case "vcs.modified":
+				b.modified = s.Value == "true"
+			}
+		}
+	}
+	if buildVersion != "" {
+		b.version = buildVersion
+	}
+	if buildCommit != "" {
+		b.revision, b.modified = buildCommit, false
+	}
+	if buildDate != "" {
+		b.time = buildDate
+	}
+	return b
+}
+
+// logAttrs: returns the build info as slog key-value pairs.
+func (b buildInfo) logAttrs() []any {
+	return []any{"module", b.path, "version", b.version, "go", b.goVersion,
+		"revision", b.revision, "time", b.time, "modified", b.modified}
+}
+
+// printVersion: prints the build info to w for -version.
+func printVersion(w io.Writer, b buildInfo) {
+	fmt.Fprintf(w, "%s %s\n", b.path, b.version)
+	fmt.Fprintf(w, "go: %s\n", b.goVersion)
+	if b.revision != "" {
+		commit := b.revision
+		if b.modified {
+			commit += " (modified)"
+		}
+		fmt.Fprintf(w, "commit: %s\n", commit)
+	}
+	if b.time != "" {
+		fmt.Fprintf(w, "date: %s\n", b.time)
+	}
+}
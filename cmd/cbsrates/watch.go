@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// watch: fetches and prints the rates every opts.interval, the text and table
+// readings headed with the time they were taken, until SIGINT or SIGTERM.
+// Weekends and public holidays are skipped since CBS does not publish then.
+// With -ttl a cache younger than that is printed instead of fetching again,
+// and as usual the cache is printed if the fetch fails; the fetch is retried
+// at the next interval.
+//
+// A signal only stops the loop between readings, so that one that is being
+// saved to the database is finished before the database is closed.
+func watch(opts options, db *sql.DB) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// On a terminal the screen is cleared before each reading so that only
+	// the latest one shows.
+	clear := opts.output == "" && term.IsTerminal(int(os.Stdout.Fd()))
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		if now := clock(); isFetchDay(now, opts.holidays) {
+			// printCurrent writes the heading with the text or table
+			// output, to -o if set, so that the other formats stay
+			// machine-readable.
+			reading := opts
+			reading.heading = readingHeading(now, clear)
+			if err := printCurrent(reading, db, opts.ttl == 0 || opts.noCache); err != nil {
+				slog.Error(err.Error())
+			}
+		} else {
+			slog.Info("CBS does not publish rates today, waiting", "next", now.Add(opts.interval).Format(time.DateTime))
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("stopping")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readingHeading: returns the heading of the -watch reading taken at now,
+// after the escape that clears the screen if clear is set.
+func readingHeading(now time.Time, clear bool) string {
+	heading := fmt.Sprintf("=== %s ===\n", now.Format("2006-01-02 15:04:05"))
+	if clear {
+		heading = "\x1b[H\x1b[2J" + heading
+	}
+	return heading
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+func TestWatchReadingFormats(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.html")
+	if err := os.WriteFile(page, []byte(testutil.SampleHTML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	heading := readingHeading(time.Date(2024, time.June, 3, 9, 0, 0, 0, time.Local), true)
+
+	tests := []struct {
+		name   string
+		opts   options
+		headed bool
+		isJSON bool
+	}{
+		{"text", options{format: "text"}, true, false},
+		{"table", options{format: "table"}, true, false},
+		{"json", options{format: "text", asJSON: true}, false, true},
+		{"format json", options{format: "json"}, false, true},
+		{"csv", options{format: "csv"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.currencies = []string{"USD", "EUR"}
+			opts.file = page
+			opts.output = filepath.Join(dir, tt.name+".out")
+			opts.heading = heading
+			if err := printCurrent(opts, nil, false); err != nil {
+				t.Fatalf("printCurrent: %v", err)
+			}
+			out, err := os.ReadFile(opts.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if headed := strings.HasPrefix(string(out), heading); headed != tt.headed {
+				t.Errorf("headed = %v, want %v:\n%s", headed, tt.headed, out)
+			}
+			if tt.isJSON && !json.Valid(out) {
+				t.Errorf("the reading is not valid JSON:\n%s", out)
+			}
+		})
+	}
+}
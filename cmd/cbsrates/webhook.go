@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/notify/slack"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+)
+
+// webhookTimeout: how long postWebhook waits for the webhook to answer.
+const webhookTimeout = 10 * time.Second
+
+// postWebhook: POSTs the rates to url as a JSON array, the same as -json
+// prints, with the fetch time in the X-CBS-Rates-Timestamp header. It returns
+// an error if the webhook does not answer with a 2xx status.
+func postWebhook(url string, rates []cbsrates.Rate, fetchedAt time.Time) error {
+	body, err := json.Marshal(newJSONRates(rates, fetchedAt, nil, false, false))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CBS-Rates-Timestamp", fetchedAt.Format(time.RFC3339))
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// notify: sends the freshly fetched rates to the -webhook and -slack-webhook,
+// and emails them if -smtp-host is set. The rates are already cached by then,
+// so failing to send them is only a warning.
+func notify(opts options, rs []cbsrates.Rate, fetchedAt time.Time) {
+	if opts.webhook != "" {
+		if err := postWebhook(opts.webhook, rs, fetchedAt); err != nil {
+			slog.Warn("could not notify the webhook", "url", opts.webhook, "err", err)
+		}
+	}
+	if opts.slackWebhook != "" {
+		if err := slack.Send(opts.slackWebhook, newRecordList(rs)); err != nil {
+			slog.Warn("could not notify Slack", "err", err)
+		}
+	}
+	if opts.smtpHost != "" {
+		if err := sendEmail(opts, rs, fetchedAt); err != nil {
+			slog.Warn(err.Error())
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxHeader: the header row of each sheet -export-xlsx writes.
+var xlsxHeader = []any{"Date", "Buying", "Selling", "Mid-Rate"}
+
+// exportXLSX: writes every rate in db to an Excel workbook at path, with one
+// sheet per currency of its rates by day and a line chart of its mid-rate.
+// Rates CBS did not publish are left as empty cells, which the chart skips.
+func exportXLSX(path string, db *sql.DB) error {
+	rows, err := db.Query(`SELECT date, currency, buying, selling, mid_rate FROM rates ORDER BY currency, date`)
+	if err != nil {
+		return fmt.Errorf("could not query the rates: %w", err)
+	}
+	defer rows.Close()
+
+	f := excelize.NewFile()
+	defer f.Close()
+	// next: the row of the next rate on the sheet of each currency.
+	next := map[string]int{}
+	var currencies []string
+	for rows.Next() {
+		var date, curr string
+		var buying, selling, midRate sql.NullFloat64
+		if err := rows.Scan(&date, &curr, &buying, &selling, &midRate); err != nil {
+			return fmt.Errorf("could not read the rates: %w", err)
+		}
+		if _, ok := next[curr]; !ok {
+			if _, err := f.NewSheet(curr); err != nil {
+				return err
+			}
+			if err := f.SetSheetRow(curr, "A1", &xlsxHeader); err != nil {
+				return err
+			}
+			f.SetColWidth(curr, "A", "D", 12)
+			next[curr] = 2
+			currencies = append(currencies, curr)
+		}
+		row := []any{date, cellRate(buying), cellRate(selling), cellRate(midRate)}
+		if err := f.SetSheetRow(curr, fmt.Sprintf("A%d", next[curr]), &row); err != nil {
+			return err
+		}
+		next[curr]++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not read the rates: %w", err)
+	}
+	if len(currencies) == 0 {
+		return errors.New("the -db database has no rates to export")
+	}
+
+	for _, curr := range currencies {
+		last := next[curr] - 1
+		err := f.AddChart(curr, "F2", &excelize.Chart{
+			Type: excelize.Line,
+			Series: []excelize.ChartSeries{{
+				Name:       fmt.Sprintf("'%s'!$D$1", curr),
+				Categories: fmt.Sprintf("'%s'!$A$2:$A$%d", curr, last),
+				Values:     fmt.Sprintf("'%s'!$D$2:$D$%d", curr, last),
+			}},
+			Title:        []excelize.RichTextRun{{Text: curr + " mid-rate in SCR"}},
+			Legend:       excelize.ChartLegend{Position: "none"},
+			ShowBlanksAs: "gap",
+			Dimension:    excelize.ChartDimension{Width: 720, Height: 360},
+		})
+		if err != nil {
+			return fmt.Errorf("could not chart the %s rates: %w", curr, err)
+		}
+	}
+	// The workbook was created with an empty Sheet1, which would otherwise
+	// come first.
+	if err := f.DeleteSheet("Sheet1"); err != nil {
+		return err
+	}
+	f.SetActiveSheet(0)
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("could not write the workbook: %w", err)
+	}
+	return nil
+}
+
+// cellRate: returns the cell value of a rate, nil for one that was not
+// published.
+func cellRate(v sql.NullFloat64) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.Float64
+}
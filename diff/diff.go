@@ -0,0 +1,75 @@
+// Package diff compares the CBS rates of two days.
+package diff
+
+import (
+	"math"
+	"strconv"
+
+	"gitlab.com/eoea/cbsrates/rates"
+)
+
+// Change: one rate on both days, as in the records, with the change from the
+// first to the second. The change is zero if either day has no rate.
+type Change struct {
+	From  string
+	To    string
+	Delta float64
+	// Pct is Delta in percent of From.
+	Pct float64
+}
+
+// RateDiff: how the rates of a currency changed between two days.
+type RateDiff struct {
+	Currency string
+	Buying   Change
+	Selling  Change
+	MidRate  Change
+}
+
+// change: returns the change of the rate named by its JSON key from a's
+// record to b's.
+func change(a, b rates.RateRecord, rate string, from, to string) Change {
+	c := Change{From: from, To: to}
+	x, errX := strconv.ParseFloat(from, 64)
+	y, errY := strconv.ParseFloat(to, 64)
+	if errX == nil && errY == nil {
+		// Rounded so that the float arithmetic does not add digits CBS
+		// did not publish.
+		c.Delta = math.Round((y-x)*1e6) / 1e6
+		c.Pct = b.ChangePctOf(a, rate)
+	}
+	return c
+}
+
+// Compare: returns the change of each currency's rates from a, the records of
+// the first day, to b, those of the second, in the order of b followed by the
+// currencies that are only in a.
+func Compare(a, b []rates.RateRecord) []RateDiff {
+	first := make(map[string]rates.RateRecord, len(a))
+	for _, r := range a {
+		first[r.Currency] = r
+	}
+	diffs := make([]RateDiff, 0, len(b))
+	seen := make(map[string]bool, len(b))
+	for _, r := range b {
+		diffs = append(diffs, compare(first[r.Currency], r))
+		seen[r.Currency] = true
+	}
+	for _, r := range a {
+		if !seen[r.Currency] {
+			diffs = append(diffs, compare(r, rates.RateRecord{Currency: r.Currency}))
+		}
+	}
+	return diffs
+}
+
+// compare: returns the change of the rates from a to b, which are records of
+// the same currency.
+func compare(a, b rates.RateRecord) RateDiff {
+	return RateDiff{
+		Currency: b.Currency,
+		Buying:   change(a, b, "buying", a.Buying, b.Buying),
+		Selling:  change(a, b, "selling", a.Selling, b.Selling),
+		MidRate:  change(a, b, "mid_rate", a.MidRate, b.MidRate),
+	}
+}
@@ -0,0 +1,101 @@
+// Package slack posts the CBS rates to a Slack incoming webhook.
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/rates"
+)
+
+// Timeout: how long Send waits for Slack to answer.
+const Timeout = 10 * time.Second
+
+// text: a Block Kit text object.
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// block: a Block Kit header or section block.
+type block struct {
+	Type   string `json:"type"`
+	Text   *text  `json:"text,omitempty"`
+	Fields []text `json:"fields,omitempty"`
+}
+
+// message: the payload of an incoming webhook. Text is shown in
+// notifications, where blocks are not.
+type message struct {
+	Text   string  `json:"text"`
+	Blocks []block `json:"blocks"`
+}
+
+// orNA: returns rate, or N/A if CBS did not publish it.
+func orNA(rate string) string {
+	if rate == "" {
+		return "N/A"
+	}
+	return rate
+}
+
+// newMessage: builds a message with a "CBS Rates — date" header and a section
+// per record that lists its rates as fields.
+func newMessage(date time.Time, records []rates.RateRecord) message {
+	title := "CBS Rates — " + date.Format("2006-01-02")
+	msg := message{
+		Text:   title,
+		Blocks: []block{{Type: "header", Text: &text{Type: "plain_text", Text: title}}},
+	}
+	for _, r := range records {
+		msg.Blocks = append(msg.Blocks, block{
+			Type: "section",
+			Text: &text{Type: "mrkdwn", Text: "*" + r.Currency + "*"},
+			Fields: []text{
+				{Type: "mrkdwn", Text: "*Buying*\n" + orNA(r.Buying)},
+				{Type: "mrkdwn", Text: "*Selling*\n" + orNA(r.Selling)},
+				{Type: "mrkdwn", Text: "*Mid-rate*\n" + orNA(r.MidRate)},
+			},
+		})
+	}
+	return msg
+}
+
+// now: returns the time that Send dates a message with when none of the
+// records says when CBS published it. It is a variable so that tests can
+// replace it.
+var now = time.Now
+
+// messageDate: returns the day CBS published the records for, from the first
+// record that has an EffectiveDate, or now if none does.
+func messageDate(records []rates.RateRecord) time.Time {
+	for _, r := range records {
+		if date, err := time.Parse("2006-01-02", r.EffectiveDate); err == nil {
+			return date
+		}
+	}
+	return now()
+}
+
+// Send: posts the records to the Slack incoming webhook at webhookURL as a
+// message dated the day CBS published them, returning an error if Slack does
+// not accept it.
+func Send(webhookURL string, records []rates.RateRecord) error {
+	body, err := json.Marshal(newMessage(messageDate(records), records))
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: Timeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Package parser turns the rendered CBS rates page into the rate records
+// published to other programs. It only needs the page's HTML, so it can be
+// used without playwright, e.g. on a cached page.
+package parser
+
+import (
+	"strconv"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/rates"
+)
+
+// formatRate: formats a rate with the given number of decimals, or the way
+// CBS publishes it if precision is negative, leaving it empty if it was not
+// published.
+func formatRate(v float64, precision int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', precision, 64)
+}
+
+// Record: converts a parsed rate to its record, with the rates the way CBS
+// publishes them.
+func Record(rate cbsrates.Rate) rates.RateRecord {
+	return FormatRecord(rate, -1)
+}
+
+// FormatRecord: converts a parsed rate to its record, with the rates rounded
+// to precision decimals; a negative precision keeps them as CBS publishes
+// them.
+func FormatRecord(rate cbsrates.Rate, precision int) rates.RateRecord {
+	return rates.RateRecord{
+		Currency:      rate.Currency,
+		Buying:        formatRate(rate.Buying, precision),
+		Selling:       formatRate(rate.Selling, precision),
+		MidRate:       formatRate(rate.MidRate, precision),
+		Spread:        formatRate(rate.Spread(), precision),
+		EffectiveDate: effectiveDate(rate),
+	}
+}
+
+// effectiveDate: formats the day CBS published rate for, or returns an empty
+// string if it is not known.
+func effectiveDate(rate cbsrates.Rate) string {
+	if rate.Date.IsZero() {
+		return ""
+	}
+	return rate.Date.Format("2006-01-02")
+}
+
+// Parse: returns the record of every currency listed on the rendered CBS rates
+// page that has any rates, in the order CBS lists them, or an error wrapping
+// cbsrates.ErrNoRates if there are none, e.g. because the page is empty or its
+// layout changed.
+func Parse(html string) ([]rates.RateRecord, error) {
+	rs, err := cbsrates.ParseRates(html)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]rates.RateRecord, 0, len(rs))
+	for _, rate := range rs {
+		records = append(records, Record(rate))
+	}
+	return records, nil
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/rates"
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+// readTestdata: returns the content of the file name in testdata.
+func readTestdata(t testing.TB, name string) string {
+	t.Helper()
+	content, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want map[string]rates.RateRecord
+	}{
+		{
+			name: "normal",
+			html: testutil.SampleHTML,
+			want: map[string]rates.RateRecord{
+				"USD": {Currency: "USD", Buying: "13.9512", Selling: "14.52", MidRate: "14.2356", Spread: "0.5688", EffectiveDate: "2024-06-03"},
+				"EUR": {Currency: "EUR", Buying: "15.1023", Selling: "15.88", MidRate: "15.4911", Spread: "0.7777", EffectiveDate: "2024-06-03"},
+				"ZAR": {Currency: "ZAR", Buying: "0.74", Selling: "0.81", MidRate: "0.775", Spread: "0.07", EffectiveDate: "2024-06-03"},
+			},
+		},
+		{
+			name: "missing selling and mid-rate",
+			html: testutil.SampleHTML,
+			want: map[string]rates.RateRecord{
+				"GBP": {Currency: "GBP", Buying: "17.65", EffectiveDate: "2024-06-03"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := Parse(tt.html)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got := make(map[string]rates.RateRecord)
+			for _, r := range records {
+				got[r.Currency] = r
+			}
+			for curr, want := range tt.want {
+				if got[curr] != want {
+					t.Errorf("%s record = %+v, want %+v", curr, got[curr], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNoRates(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"empty HTML", ""},
+		{"other page structure", readTestdata(t, "other_layout.html")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := Parse(tt.html)
+			if !errors.Is(err, cbsrates.ErrNoRates) {
+				t.Errorf("Parse = %v, %v, want an error wrapping %v", records, err, cbsrates.ErrNoRates)
+			}
+		})
+	}
+}
+
+// BenchmarkParse: the whole page, as the cache is parsed on every run.
+func BenchmarkParse(b *testing.B) {
+	for range b.N {
+		if _, err := Parse(testutil.SampleHTML); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseCurrency: a single currency, which finds its sections of the
+// page with a regular expression before parsing them.
+func BenchmarkParseCurrency(b *testing.B) {
+	for range b.N {
+		if _, err := cbsrates.ParseCurrency("GBP", testutil.SampleHTML); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseFloat: what the rates cost kept as float64 rather than as the
+// strings of the records, which is what the database stores.
+func BenchmarkParseFloat(b *testing.B) {
+	b.Run("parse", func(b *testing.B) {
+		for range b.N {
+			if _, err := strconv.ParseFloat("14.2356", 64); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("format", func(b *testing.B) {
+		for range b.N {
+			_ = strconv.FormatFloat(14.2356, 'f', -1, 64)
+		}
+	})
+}
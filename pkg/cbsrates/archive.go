@@ -0,0 +1,68 @@
+package cbsrates
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// nextPageLink: the selector of the link to the next page of a paginated
+// archive, by its rel or by its text.
+const nextPageLink = `a[rel="next"], a:has-text("Next"), a:has-text("›"), a:has-text("»")`
+
+// FetchPages: loads start and then each page its "next page" link leads to,
+// calling visit with the rendered HTML of each until there is no such link or
+// visit returns false. It waits delay between pages so as not to hammer CBS,
+// and gives up on ctx as Fetch does. Errors are reported as with Fetch.
+func (s *Scraper) FetchPages(ctx context.Context, start string, delay time.Duration, visit func(ratesHTML string) (more bool, err error)) error {
+	for pageURL := start; ; {
+		page, err := s.fetch(ctx, pageURL)
+		if err != nil {
+			return err
+		}
+		more, err := visit(page.html)
+		if err != nil || !more {
+			return err
+		}
+
+		next, err := s.nextPage(pageURL)
+		// A link that does not lead anywhere else, e.g. href="#" on a
+		// page that paginates with scripts, ends the archive too.
+		if err != nil || next == "" || next == pageURL {
+			return err
+		}
+		pageURL = next
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up fetching the CBS rates: %w", ctx.Err())
+		}
+	}
+}
+
+// nextPage: returns the URL the "next page" link of the loaded page leads to,
+// resolved against its URL, current, or an empty string if it has none.
+func (s *Scraper) nextPage(current string) (string, error) {
+	link := s.page.Locator(nextPageLink).First()
+	n, err := link.Count()
+	if err != nil || n == 0 {
+		return "", err
+	}
+	href, err := link.GetAttribute("href", playwright.LocatorGetAttributeOptions{Timeout: playwright.Float(1000)})
+	if err != nil || href == "" {
+		return "", err
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page link %q: %w", href, err)
+	}
+	return next.String(), nil
+}
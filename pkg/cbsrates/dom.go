@@ -0,0 +1,77 @@
+package cbsrates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// rowSelector, codeSelector, rateSelector: select the rows of the CBS rates
+// table, the currency code heading each row and its rate cells. Unlike the
+// regular expressions in parse.go they do not depend on the inline styles of
+// the cells.
+const (
+	rowSelector  = "tr"
+	codeSelector = "th"
+	rateSelector = "td.ng-binding"
+)
+
+// currencyCode: matches a currency code heading a row.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// leadingRate: matches the rate at the start of a cell, as parseRate does.
+var leadingRate = regexp.MustCompile(`^(?:` + rateNumber + `)`)
+
+// extractRatesDOM: returns the rates of every currency in the rates table of
+// the loaded page, found with CSS selectors rather than on the page's HTML.
+// Rows without a currency code or without any rate are skipped, and rates
+// that are missing are left as zero, as with ParseRates.
+func extractRatesDOM(page playwright.Page) ([]Rate, error) {
+	rows, err := page.QuerySelectorAll(rowSelector)
+	if err != nil {
+		return nil, fmt.Errorf("could not select the rates table rows: %w", err)
+	}
+
+	var rates []Rate
+	for _, row := range rows {
+		th, err := row.QuerySelector(codeSelector)
+		if err != nil || th == nil {
+			continue
+		}
+		code, err := th.TextContent()
+		if err != nil || !currencyCode.MatchString(strings.TrimSpace(code)) {
+			continue
+		}
+		cells, err := row.QuerySelectorAll(rateSelector)
+		if err != nil {
+			return nil, fmt.Errorf("could not select the %s rates: %w", code, err)
+		}
+
+		rate := Rate{Currency: strings.TrimSpace(code)}
+		fields := []*float64{&rate.Buying, &rate.Selling, &rate.MidRate}
+		found := false
+		for i := 0; i < len(fields) && i < len(cells); i++ {
+			text, err := cells[i].TextContent()
+			if err != nil {
+				return nil, fmt.Errorf("could not read the %s rates: %w", rate.Currency, err)
+			}
+			m := leadingRate.FindString(strings.TrimSpace(text))
+			if m == "" {
+				continue
+			}
+			if *fields[i], err = parseNumber(m); err != nil {
+				return nil, fmt.Errorf("could not parse %s rate %q: %v", rate.Currency, m, err)
+			}
+			found = true
+		}
+		if found {
+			rates = append(rates, rate)
+		}
+	}
+	if len(rates) == 0 {
+		return nil, ErrNoRates
+	}
+	return rates, nil
+}
@@ -0,0 +1,39 @@
+package cbsrates
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+func TestExtractRatesDOMMatchesParseRates(t *testing.T) {
+	testutil.SkipWithoutPlaywright(t)
+	s, err := NewScraper(FetchOptions{})
+	if err != nil {
+		t.Fatalf("NewScraper: %v", err)
+	}
+	defer s.Close()
+	if err := s.page.SetContent(testutil.SampleHTML); err != nil {
+		t.Fatalf("could not load the sample page: %v", err)
+	}
+
+	fromDOM, err := extractRatesDOM(s.page)
+	if err != nil {
+		t.Fatalf("extractRatesDOM: %v", err)
+	}
+	fromHTML, err := ParseRates(testutil.SampleHTML)
+	if err != nil {
+		t.Fatalf("ParseRates: %v", err)
+	}
+	if len(fromDOM) != len(fromHTML) {
+		t.Fatalf("extractRatesDOM found %d rates, ParseRates %d:\n%+v\n%+v", len(fromDOM), len(fromHTML), fromDOM, fromHTML)
+	}
+	for i := range fromHTML {
+		// Only ParseRates dates the rates; Fetch dates those of the DOM.
+		fromHTML[i].Date = time.Time{}
+		if fromDOM[i] != fromHTML[i] {
+			t.Errorf("rate %d: extractRatesDOM = %+v, ParseRates = %+v", i, fromDOM[i], fromHTML[i])
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package cbsrates
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotListed: returned by ParseCurrency when the currency does not appear
+// on the rates page.
+var ErrNotListed = errors.New("is not listed on the rates page")
+
+// ErrNoRates: returned by ParseCurrency when the currency is listed but none
+// of its rates are.
+var ErrNoRates = errors.New("no rates found")
+
+// NoRatesError: returned by ParseCurrency when the currency is listed but none
+// of its rates are. It wraps ErrNoRates and holds the HTML section the rates
+// were looked for in, so that the page can be reported.
+type NoRatesError struct {
+	Currency string
+	Section  string
+}
+
+func (e *NoRatesError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Currency, ErrNoRates)
+}
+
+func (e *NoRatesError) Unwrap() error { return ErrNoRates }
+
+// ErrNoDate: returned by PublishedDate when the rates page does not say which
+// day its rates are for.
+var ErrNoDate = errors.New("no publication date on the rates page")
+
+// extractRates: takes a currency and a rendered HTML with the rates information
+// and returns the HTML section of every line the currency appears on, in page
+// order, or an error if the currency does not appear in ratesHTML. The code can
+// turn up outside the table too, e.g. in a header, so ParseCurrency picks the
+// section that has the currency's row.
+//
+// sectionLines is the number of lines (or section) about the information that
+// I need such as the selling, buying and mid-rates for the respective currency,
+// after the line with the code. The sections may overlap, so that a mention
+// just above the row does not cut the row's own section short. Currency is any
+// code listed in ratesHTML, e.g. USD.
+func extractRates(curr string, ratesHTML string) ([]string, error) {
+	const sectionLines = 4
+	line, err := regexp.Compile(fmt.Sprintf(".*%s.*", regexp.QuoteMeta(curr)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile regex: %w", err)
+	}
+	var sections []string
+	for _, loc := range line.FindAllStringIndex(ratesHTML, -1) {
+		end := loc[1]
+		for range sectionLines {
+			i := strings.IndexByte(ratesHTML[end:], '\n')
+			if i < 0 {
+				end = len(ratesHTML)
+				break
+			}
+			end += i + 1
+		}
+		sections = append(sections, ratesHTML[loc[0]:end])
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("%s %w", curr, ErrNotListed)
+	}
+	return sections, nil
+}
+
+// Rate: the buying, selling and mid-rate for a single currency against SCR. A
+// zero rate means CBS did not publish it.
+type Rate struct {
+	Currency string
+	Buying   float64
+	Selling  float64
+	MidRate  float64
+	// Date is the day CBS published the rates for, or the zero time if the
+	// page does not say.
+	Date time.Time
+	// Period is what the rates are averaged over. The page does not say, so
+	// the parsing functions leave it empty, which means Daily.
+	Period Period
+}
+
+// Spread: returns the selling rate minus the buying rate, which is what CBS
+// makes on the exchange, or zero if either rate was not published.
+func (r Rate) Spread() float64 {
+	if r.Buying == 0 || r.Selling == 0 {
+		return 0
+	}
+	// Rounded so that the float arithmetic does not add digits CBS did not
+	// publish.
+	return math.Round((r.Selling-r.Buying)*1e6) / 1e6
+}
+
+// DerivedMidRate: returns the mid-rate, worked out as the average of the
+// buying and selling rates when CBS did not publish it, or zero if either of
+// those was not published either.
+func (r Rate) DerivedMidRate() float64 {
+	if r.MidRate != 0 {
+		return r.MidRate
+	}
+	if r.Buying == 0 || r.Selling == 0 {
+		return 0
+	}
+	return math.Round((r.Buying+r.Selling)/2*1e6) / 1e6
+}
+
+// SpreadPct: returns the spread as a percentage of the DerivedMidRate, or zero
+// if either cannot be worked out.
+func (r Rate) SpreadPct() float64 {
+	mid := r.DerivedMidRate()
+	if mid == 0 {
+		return 0
+	}
+	return r.Spread() / mid * 100
+}
+
+// dateText: matches the dates as they may be written on the CBS rates page,
+// e.g. 03/06/2024, 2024-06-03, 3 June 2024, June 3rd, 2024 or 03-Jun-2024.
+var dateText = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4}|\d{1,2}(?:st|nd|rd|th)? [A-Z][a-z]+,? \d{4}|[A-Z][a-z]+ \d{1,2}(?:st|nd|rd|th)?,? \d{4}|\d{1,2}-[A-Z][a-z]{2}-\d{4})\b`)
+
+// ordinal: matches the suffix of an ordinal day, e.g. the rd of 3rd.
+var ordinal = regexp.MustCompile(`(\d)(?:st|nd|rd|th)`)
+
+// dateLayouts: the layouts dateText matches, once ordinals and commas are
+// removed. CBS writes numeric dates day first.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2/1/2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2 2006",
+	"Jan 2 2006",
+	"2-Jan-2006",
+}
+
+// PublishedDate: returns the day the rates on the rendered CBS rates page are
+// for, which is the first date written on it, or ErrNoDate if there is none.
+func PublishedDate(ratesHTML string) (time.Time, error) {
+	for _, text := range dateText.FindAllString(ratesHTML, -1) {
+		text = strings.ReplaceAll(ordinal.ReplaceAllString(text, "$1"), ",", "")
+		for _, layout := range dateLayouts {
+			if date, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+				return date, nil
+			}
+		}
+	}
+	return time.Time{}, ErrNoDate
+}
+
+// rateNumber: matches a rate as CBS may write it, with any number of decimals
+// and, for rates such as JPY per 100, with commas between the thousands.
+const rateNumber = `\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?`
+
+// parseNumber: parses a rate matched by rateNumber.
+func parseNumber(text string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64)
+}
+
+// rateCell: matches one rate cell of the CBS table. The cell itself, or the
+// number in it, may be missing when CBS does not publish that rate.
+const rateCell = `(?:\s+<td style="font-size: 12px;text-align: left" class="ng-binding">\s*(` + rateNumber + `)?[^<]*</td>)?`
+
+// rateRow: matches a row of the CBS table; the currency code is followed by
+// the buying, selling and mid-rate cells.
+var rateRow = regexp.MustCompile(`<th style="height: 30px;font-size: 12px">(\w+)</th>` + rateCell + rateCell + rateCell)
+
+// currencyCell: matches the currency code cell that starts each row of the CBS
+// table.
+var currencyCell = regexp.MustCompile(`<th style="height: 30px;font-size: 12px">([A-Z]{3})</th>`)
+
+// Currencies: returns the currency codes listed in ratesHTML, in the order CBS
+// lists them, so that currencies CBS adds are picked up automatically.
+func Currencies(ratesHTML string) []string {
+	var currencies []string
+	seen := make(map[string]bool)
+	for _, m := range currencyCell.FindAllStringSubmatch(ratesHTML, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			currencies = append(currencies, m[1])
+		}
+	}
+	return currencies
+}
+
+// parseRate: takes the section of the rates after extractRates() and returns
+// the currency and its buying, selling and mid-rates. Rates that are missing
+// from the section, as often happens with the GBP selling and mid-rates, are
+// left as zero.
+func parseRate(section string) (Rate, error) {
+	matches := rateRow.FindAllStringSubmatch(section, -1)
+	if len(matches) == 0 || matches[0][2]+matches[0][3]+matches[0][4] == "" {
+		return Rate{}, ErrNoRates
+	}
+
+	rate := Rate{Currency: matches[0][1]}
+	fields := []*float64{&rate.Buying, &rate.Selling, &rate.MidRate}
+	for i, field := range fields {
+		if matches[0][i+2] == "" {
+			continue
+		}
+		v, err := parseNumber(matches[0][i+2])
+		if err != nil {
+			return Rate{}, fmt.Errorf("could not parse %s rate %q: %v", rate.Currency, matches[0][i+2], err)
+		}
+		*field = v
+	}
+	return rate, nil
+}
+
+// ParseCurrency: returns the rates of curr from the rendered CBS rates page,
+// dated with the page's PublishedDate. Of the sections extractRates finds, the
+// rates come from the one with a row of curr that has the most of them, the
+// first if several do. A currency that is listed without any rates is
+// returned with a *NoRatesError holding its first section.
+func ParseCurrency(curr, ratesHTML string) (Rate, error) {
+	sections, err := extractRates(curr, ratesHTML)
+	if err != nil {
+		return Rate{}, err
+	}
+	var best Rate
+	bestCount := 0
+	var parseErr error
+	for _, section := range sections {
+		rate, err := parseRate(section)
+		switch {
+		case errors.Is(err, ErrNoRates):
+			continue
+		case err != nil:
+			parseErr = cmp.Or(parseErr, err)
+			continue
+		case rate.Currency != curr:
+			// The section starts at another mention of curr and runs
+			// into the row of another currency.
+			continue
+		}
+		if n := rate.published(); n > bestCount {
+			best, bestCount = rate, n
+		}
+	}
+	if bestCount == 0 {
+		if parseErr != nil {
+			return Rate{}, parseErr
+		}
+		return Rate{}, &NoRatesError{Currency: curr, Section: sections[0]}
+	}
+	best.Date, _ = PublishedDate(ratesHTML)
+	return best, nil
+}
+
+// published: returns how many of the buying, selling and mid-rate of r CBS
+// published.
+func (r Rate) published() int {
+	n := 0
+	for _, v := range []float64{r.Buying, r.Selling, r.MidRate} {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// ParseRates: returns the rates of every currency listed on the rendered CBS
+// rates page that has any, or an error if there are none.
+func ParseRates(ratesHTML string) ([]Rate, error) {
+	var rates []Rate
+	for _, curr := range Currencies(ratesHTML) {
+		rate, err := ParseCurrency(curr, ratesHTML)
+		if errors.Is(err, ErrNoRates) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 {
+		return nil, ErrNoRates
+	}
+	return rates, nil
+}
@@ -0,0 +1,131 @@
+package cbsrates
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/eoea/cbsrates/testutil"
+)
+
+func TestParseCurrency(t *testing.T) {
+	date := time.Date(2024, time.June, 3, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		curr string
+		want Rate
+	}{
+		{"USD", Rate{Currency: "USD", Buying: 13.9512, Selling: 14.52, MidRate: 14.2356, Date: date}},
+		{"EUR", Rate{Currency: "EUR", Buying: 15.1023, Selling: 15.88, MidRate: 15.4911, Date: date}},
+		// CBS often publishes only the GBP buying rate.
+		{"GBP", Rate{Currency: "GBP", Buying: 17.65, Date: date}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.curr, func(t *testing.T) {
+			got, err := ParseCurrency(tt.curr, testutil.SampleHTML)
+			if err != nil {
+				t.Fatalf("ParseCurrency(%q): %v", tt.curr, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCurrency(%q) = %+v, want %+v", tt.curr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCurrencyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		curr string
+		html string
+		want error
+	}{
+		{"not listed", "JPY", testutil.SampleHTML, ErrNotListed},
+		{"empty page", "USD", "", ErrNotListed},
+		{"no rates", "USD", `<th style="height: 30px;font-size: 12px">USD</th>
+<td style="font-size: 12px;text-align: left" class="ng-binding"></td>
+`, ErrNoRates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCurrency(tt.curr, tt.html)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ParseCurrency(%q) error = %v, want %v", tt.curr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(testutil.SampleHTML)
+	if err != nil {
+		t.Fatalf("ParseRates: %v", err)
+	}
+	var got []string
+	for _, rate := range rates {
+		got = append(got, rate.Currency)
+	}
+	want := []string{"USD", "EUR", "GBP", "ZAR"}
+	if len(got) != len(want) {
+		t.Fatalf("ParseRates currencies = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ParseRates currencies = %v, want %v", got, want)
+		}
+	}
+	if gbp := rates[2]; gbp.Selling != 0 || gbp.MidRate != 0 {
+		t.Errorf("GBP selling and mid-rate = %v and %v, want both missing", gbp.Selling, gbp.MidRate)
+	}
+
+	if _, err := ParseRates(""); !errors.Is(err, ErrNoRates) {
+		t.Errorf("ParseRates of an empty page error = %v, want %v", err, ErrNoRates)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"13.9512", 13.9512},
+		{"13.9", 13.9},
+		{"14", 14},
+		{"0.123456", 0.123456},
+		{"1,234.5678", 1234.5678},
+		{"1,234,567", 1234567},
+	}
+	for _, tt := range tests {
+		if got, err := parseNumber(tt.text); err != nil || got != tt.want {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRateNumbers(t *testing.T) {
+	cell := func(v string) string {
+		return "\n" + `<td style="font-size: 12px;text-align: left" class="ng-binding">` + v + "</td>"
+	}
+	tests := []struct {
+		name  string
+		cells [3]string
+		want  Rate
+	}{
+		{"four decimals", [3]string{"13.9512", "14.5200", "14.2356"}, Rate{Buying: 13.9512, Selling: 14.52, MidRate: 14.2356}},
+		{"varying decimals", [3]string{"13.9", "14", "14.23561"}, Rate{Buying: 13.9, Selling: 14, MidRate: 14.23561}},
+		{"thousands separators", [3]string{"1,234.56", "1,300.1", "1,267.33"}, Rate{Buying: 1234.56, Selling: 1300.1, MidRate: 1267.33}},
+		{"text after the rate", [3]string{"9.1234 per 100", "9.5", ""}, Rate{Buying: 9.1234, Selling: 9.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := `<th style="height: 30px;font-size: 12px">JPY</th>` + cell(tt.cells[0]) + cell(tt.cells[1]) + cell(tt.cells[2]) + "\n"
+			got, err := ParseCurrency("JPY", html)
+			if err != nil {
+				t.Fatalf("ParseCurrency: %v", err)
+			}
+			tt.want.Currency = "JPY"
+			if got != tt.want {
+				t.Errorf("ParseCurrency = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package cbsrates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Scraper: a browser kept open to load CBS pages one after the other, e.g. the
+// daily and then the weekly rates, without starting a browser for each. It is
+// not safe for concurrent use, except for Close.
+type Scraper struct {
+	pw      *playwright.Playwright
+	browser playwright.Browser
+	context playwright.BrowserContext
+	page    playwright.Page
+	// errorScreenshot is FetchOptions.ErrorScreenshot.
+	errorScreenshot string
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewScraper: starts playwright and the browser that opts asks for, with a
+// page ready to load. The Scraper must be closed once done with.
+func NewScraper(opts FetchOptions) (*Scraper, error) {
+	pw, err := playwright.Run()
+	if err != nil {
+		return nil, fmt.Errorf("could not start playwright: %w", err)
+	}
+	s := &Scraper{pw: pw, errorScreenshot: opts.ErrorScreenshot}
+
+	name := opts.Browser
+	if name == "" {
+		name = "firefox"
+	}
+	bt, err := browserType(pw, name)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	launchOpts := playwright.BrowserTypeLaunchOptions{Headless: playwright.Bool(!opts.Headed)}
+	if opts.SlowMo > 0 {
+		launchOpts.SlowMo = playwright.Float(float64(opts.SlowMo.Milliseconds()))
+	}
+	if opts.Proxy != "" {
+		if launchOpts.Proxy, err = ParseProxy(opts.Proxy); err != nil {
+			s.Close()
+			return nil, err
+		}
+		if opts.ProxyBypass != "" {
+			launchOpts.Proxy.Bypass = playwright.String(opts.ProxyBypass)
+		}
+	}
+	if s.browser, err = bt.Launch(launchOpts); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("could not launch browser: %w", err)
+	}
+	contextOpts := playwright.BrowserNewContextOptions{IgnoreHttpsErrors: playwright.Bool(true)}
+	if opts.UserAgent != "" {
+		contextOpts.UserAgent = playwright.String(opts.UserAgent)
+	}
+	if opts.Locale != "" {
+		contextOpts.Locale = playwright.String(opts.Locale)
+	}
+	if s.context, err = s.browser.NewContext(contextOpts); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("could not create new context: %w", err)
+	}
+	if s.page, err = s.context.NewPage(); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("could not create page: %w", err)
+	}
+	return s, nil
+}
+
+// Fetch: loads url in the Scraper's page and returns the rendered content as
+// an HTML string. Errors are reported as with FetchHTML, but the fetch can only
+// give up on ctx while loading the page.
+func (s *Scraper) Fetch(ctx context.Context, url string) (string, error) {
+	page, err := s.fetch(ctx, url)
+	return page.html, err
+}
+
+// fetch: does the work of Fetch, handing the time left before ctx's deadline
+// to page.Goto. The rates are also read from the loaded page with
+// extractRatesDOM; failing to is not an error since the HTML can still be
+// parsed. If that fails too, the page is screenshotted to s.errorScreenshot,
+// if set.
+func (s *Scraper) fetch(ctx context.Context, url string) (fetchedPage, error) {
+	// Playwright does not take a context, so the time left before the deadline
+	// is handed to page.Goto as its timeout instead; no deadline means no
+	// timeout at all.
+	gotoOpts := playwright.PageGotoOptions{Timeout: playwright.Float(0)}
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return fetchedPage{}, fmt.Errorf("no time left to load the CBS rates page: %w", context.DeadlineExceeded)
+		}
+		gotoOpts.Timeout = playwright.Float(float64(left.Milliseconds()))
+	}
+	if _, err := s.page.Goto(url, gotoOpts); err != nil {
+		if errors.Is(err, playwright.ErrTimeout) {
+			return fetchedPage{}, fmt.Errorf("CBS rates page did not load in time: %w: %w", context.DeadlineExceeded, err)
+		}
+		return fetchedPage{}, fmt.Errorf("%w: %w", ErrPageLoad, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return fetchedPage{}, fmt.Errorf("gave up fetching the CBS rates: %w", err)
+	}
+	content, err := s.page.Content()
+	if err != nil {
+		return fetchedPage{}, fmt.Errorf("could not get content: %w", err)
+	}
+	rates, _ := extractRatesDOM(s.page)
+	if _, err := ParseRates(content); len(rates) == 0 && err != nil && s.errorScreenshot != "" {
+		// The screenshot is only there to help, so failing to take it
+		// is not an error either.
+		s.page.Screenshot(playwright.PageScreenshotOptions{Path: playwright.String(s.errorScreenshot), FullPage: playwright.Bool(true)})
+	}
+	return fetchedPage{html: content, rates: rates}, nil
+}
+
+// Close: closes the browser and stops playwright. It can be called more than
+// once, e.g. to end a Fetch from another goroutine; only the first call does
+// anything.
+func (s *Scraper) Close() error {
+	s.closeOnce.Do(func() {
+		var errs []error
+		if s.context != nil {
+			errs = append(errs, s.context.Close())
+		}
+		if s.browser != nil {
+			errs = append(errs, s.browser.Close())
+		}
+		errs = append(errs, s.pw.Stop())
+		s.closeErr = errors.Join(errs...)
+	})
+	return s.closeErr
+}
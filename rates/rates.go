@@ -0,0 +1,61 @@
+// Package rates holds the record types used to publish the CBS rates to other
+// programs.
+package rates
+
+import "strconv"
+
+// RateRecord: the buying, selling and mid-rate for a single currency against
+// SCR, as published by CBS, with their spread. A rate that CBS did not publish
+// is left empty.
+type RateRecord struct {
+	// Currency is the ISO 4217 code of the currency. It is left out of the
+	// JSON, where Records already keys the records by it.
+	Currency string `json:"-"`
+	Buying   string `json:"buying"`
+	Selling  string `json:"selling"`
+	MidRate  string `json:"mid_rate"`
+	// Spread is the selling rate minus the buying rate, left empty if
+	// either of them is.
+	Spread string `json:"spread"`
+	// EffectiveDate is the day CBS published the rates for, as YYYY-MM-DD,
+	// left out if the page does not say.
+	EffectiveDate string `json:"effective_date,omitempty"`
+}
+
+// Records: rate records keyed by their ISO 4217 currency code.
+type Records map[string]RateRecord
+
+// changePct: returns the change from prev to v in percent, or zero if either
+// is empty or not a rate.
+func changePct(v, prev string) float64 {
+	a, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0
+	}
+	b, err := strconv.ParseFloat(prev, 64)
+	if err != nil || b == 0 {
+		return 0
+	}
+	return (a - b) / b * 100
+}
+
+// ChangePctOf: returns the change in percent of one rate of r since prev, the
+// record of an earlier day. The rate is named by its JSON key: buying,
+// selling or mid_rate. It is zero if the rate is missing from either record.
+func (r RateRecord) ChangePctOf(prev RateRecord, rate string) float64 {
+	switch rate {
+	case "buying":
+		return changePct(r.Buying, prev.Buying)
+	case "selling":
+		return changePct(r.Selling, prev.Selling)
+	case "mid_rate":
+		return changePct(r.MidRate, prev.MidRate)
+	}
+	return 0
+}
+
+// ChangePct: returns the change in percent of the mid-rate of r since prev,
+// the record of an earlier day, or zero if either has no mid-rate.
+func (r RateRecord) ChangePct(prev RateRecord) float64 {
+	return r.ChangePctOf(prev, "mid_rate")
+}
@@ -0,0 +1,408 @@
+// The CBS rates as a gRPC service, served by cbsrates -grpc.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: ratespb/cbsrates.proto
+
+package ratespb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetRatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// currencies are the ISO 4217 codes to return; empty means those of
+	// -currencies.
+	Currencies []string `protobuf:"bytes,1,rep,name=currencies,proto3" json:"currencies,omitempty"`
+}
+
+func (x *GetRatesRequest) Reset() {
+	*x = GetRatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ratespb_cbsrates_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRatesRequest) ProtoMessage() {}
+
+func (x *GetRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratespb_cbsrates_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRatesRequest.ProtoReflect.Descriptor instead.
+func (*GetRatesRequest) Descriptor() ([]byte, []int) {
+	return file_ratespb_cbsrates_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetRatesRequest) GetCurrencies() []string {
+	if x != nil {
+		return x.Currencies
+	}
+	return nil
+}
+
+type GetRatesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rates []*RateRecord `protobuf:"bytes,1,rep,name=rates,proto3" json:"rates,omitempty"`
+}
+
+func (x *GetRatesResponse) Reset() {
+	*x = GetRatesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ratespb_cbsrates_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRatesResponse) ProtoMessage() {}
+
+func (x *GetRatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratespb_cbsrates_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRatesResponse.ProtoReflect.Descriptor instead.
+func (*GetRatesResponse) Descriptor() ([]byte, []int) {
+	return file_ratespb_cbsrates_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetRatesResponse) GetRates() []*RateRecord {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+type StreamRatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// currencies are as in GetRatesRequest.
+	Currencies []string `protobuf:"bytes,1,rep,name=currencies,proto3" json:"currencies,omitempty"`
+}
+
+func (x *StreamRatesRequest) Reset() {
+	*x = StreamRatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ratespb_cbsrates_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRatesRequest) ProtoMessage() {}
+
+func (x *StreamRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratespb_cbsrates_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamRatesRequest) Descriptor() ([]byte, []int) {
+	return file_ratespb_cbsrates_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamRatesRequest) GetCurrencies() []string {
+	if x != nil {
+		return x.Currencies
+	}
+	return nil
+}
+
+// RateRecord: the rates of a currency against SCR, as in the JSON records. A
+// rate that CBS did not publish is empty.
+type RateRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Currency string `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	Buying   string `protobuf:"bytes,2,opt,name=buying,proto3" json:"buying,omitempty"`
+	Selling  string `protobuf:"bytes,3,opt,name=selling,proto3" json:"selling,omitempty"`
+	MidRate  string `protobuf:"bytes,4,opt,name=mid_rate,json=midRate,proto3" json:"mid_rate,omitempty"`
+	Spread   string `protobuf:"bytes,5,opt,name=spread,proto3" json:"spread,omitempty"`
+	// effective_date is the day CBS published the rates for, as YYYY-MM-DD,
+	// or empty if the page does not say.
+	EffectiveDate string `protobuf:"bytes,6,opt,name=effective_date,json=effectiveDate,proto3" json:"effective_date,omitempty"`
+}
+
+func (x *RateRecord) Reset() {
+	*x = RateRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ratespb_cbsrates_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RateRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateRecord) ProtoMessage() {}
+
+func (x *RateRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_ratespb_cbsrates_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateRecord.ProtoReflect.Descriptor instead.
+func (*RateRecord) Descriptor() ([]byte, []int) {
+	return file_ratespb_cbsrates_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RateRecord) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *RateRecord) GetBuying() string {
+	if x != nil {
+		return x.Buying
+	}
+	return ""
+}
+
+func (x *RateRecord) GetSelling() string {
+	if x != nil {
+		return x.Selling
+	}
+	return ""
+}
+
+func (x *RateRecord) GetMidRate() string {
+	if x != nil {
+		return x.MidRate
+	}
+	return ""
+}
+
+func (x *RateRecord) GetSpread() string {
+	if x != nil {
+		return x.Spread
+	}
+	return ""
+}
+
+func (x *RateRecord) GetEffectiveDate() string {
+	if x != nil {
+		return x.EffectiveDate
+	}
+	return ""
+}
+
+var File_ratespb_cbsrates_proto protoreflect.FileDescriptor
+
+var file_ratespb_cbsrates_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x72, 0x61, 0x74, 0x65, 0x73, 0x70, 0x62, 0x2f, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74,
+	0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74,
+	0x65, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x31, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x75,
+	0x72, 0x72, 0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x22, 0x41, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52,
+	0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x05,
+	0x72, 0x61, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x63, 0x62,
+	0x73, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x61, 0x74, 0x65, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x52, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73, 0x22, 0x34, 0x0a, 0x12, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x69, 0x65,
+	0x73, 0x22, 0xb4, 0x01, 0x0a, 0x0a, 0x52, 0x61, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x16, 0x0a, 0x06,
+	0x62, 0x75, 0x79, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x75,
+	0x79, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x19,
+	0x0a, 0x08, 0x6d, 0x69, 0x64, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x69, 0x64, 0x52, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x70, 0x72,
+	0x65, 0x61, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x70, 0x72, 0x65, 0x61,
+	0x64, 0x12, 0x25, 0x0a, 0x0e, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x64,
+	0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x65, 0x66, 0x66, 0x65, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x44, 0x61, 0x74, 0x65, 0x32, 0xa2, 0x01, 0x0a, 0x0c, 0x52, 0x61, 0x74,
+	0x65, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x47, 0x0a, 0x08, 0x47, 0x65, 0x74,
+	0x52, 0x61, 0x74, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74, 0x65, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x49, 0x0a, 0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x61, 0x74, 0x65,
+	0x73, 0x12, 0x1f, 0x2e, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x17, 0x2e, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x52, 0x61, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x30, 0x01, 0x42, 0x22, 0x5a,
+	0x20, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x6f, 0x65, 0x61,
+	0x2f, 0x63, 0x62, 0x73, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2f, 0x72, 0x61, 0x74, 0x65, 0x73, 0x70,
+	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_ratespb_cbsrates_proto_rawDescOnce sync.Once
+	file_ratespb_cbsrates_proto_rawDescData = file_ratespb_cbsrates_proto_rawDesc
+)
+
+func file_ratespb_cbsrates_proto_rawDescGZIP() []byte {
+	file_ratespb_cbsrates_proto_rawDescOnce.Do(func() {
+		file_ratespb_cbsrates_proto_rawDescData = protoimpl.X.CompressGZIP(file_ratespb_cbsrates_proto_rawDescData)
+	})
+	return file_ratespb_cbsrates_proto_rawDescData
+}
+
+var file_ratespb_cbsrates_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_ratespb_cbsrates_proto_goTypes = []any{
+	(*GetRatesRequest)(nil),    // 0: cbsrates.v1.GetRatesRequest
+	(*GetRatesResponse)(nil),   // 1: cbsrates.v1.GetRatesResponse
+	(*StreamRatesRequest)(nil), // 2: cbsrates.v1.StreamRatesRequest
+	(*RateRecord)(nil),         // 3: cbsrates.v1.RateRecord
+}
+var file_ratespb_cbsrates_proto_depIdxs = []int32{
+	3, // 0: cbsrates.v1.GetRatesResponse.rates:type_name -> cbsrates.v1.RateRecord
+	0, // 1: cbsrates.v1.RatesService.GetRates:input_type -> cbsrates.v1.GetRatesRequest
+	2, // 2: cbsrates.v1.RatesService.StreamRates:input_type -> cbsrates.v1.StreamRatesRequest
+	1, // 3: cbsrates.v1.RatesService.GetRates:output_type -> cbsrates.v1.GetRatesResponse
+	3, // 4: cbsrates.v1.RatesService.StreamRates:output_type -> cbsrates.v1.RateRecord
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_ratespb_cbsrates_proto_init() }
+func file_ratespb_cbsrates_proto_init() {
+	if File_ratespb_cbsrates_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_ratespb_cbsrates_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*GetRatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ratespb_cbsrates_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetRatesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ratespb_cbsrates_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamRatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ratespb_cbsrates_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*RateRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_ratespb_cbsrates_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ratespb_cbsrates_proto_goTypes,
+		DependencyIndexes: file_ratespb_cbsrates_proto_depIdxs,
+		MessageInfos:      file_ratespb_cbsrates_proto_msgTypes,
+	}.Build()
+	File_ratespb_cbsrates_proto = out.File
+	file_ratespb_cbsrates_proto_rawDesc = nil
+	file_ratespb_cbsrates_proto_goTypes = nil
+	file_ratespb_cbsrates_proto_depIdxs = nil
+}
@@ -0,0 +1,76 @@
+// Package source fetches rates against SCR from any number of places, CBS
+// being the first, so that they can be compared.
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/eoea/cbsrates/parser"
+	"gitlab.com/eoea/cbsrates/pkg/cbsrates"
+	"gitlab.com/eoea/cbsrates/rates"
+	"golang.org/x/sync/errgroup"
+)
+
+// Source: somewhere that publishes rates against SCR, such as CBS or a bank.
+type Source interface {
+	// Name is the short identifier of the source, e.g. cbs.
+	Name() string
+	// Fetch returns the rates the source publishes now, giving up once ctx
+	// is done.
+	Fetch(ctx context.Context) ([]rates.RateRecord, error)
+}
+
+// CBS: the Central Bank of Seychelles, whose rates are fetched with the
+// browser as cbsrates.Fetch does.
+type CBS struct {
+	Options cbsrates.FetchOptions
+	// Precision is the number of decimals of the rates, or -1 for them as
+	// CBS publishes them.
+	Precision int
+}
+
+// Name: returns cbs.
+func (CBS) Name() string {
+	return "cbs"
+}
+
+// Fetch: fetches the rates of every currency on the CBS page of c.Options.
+func (c CBS) Fetch(ctx context.Context) ([]rates.RateRecord, error) {
+	rs, err := cbsrates.Fetch(ctx, c.Options)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]rates.RateRecord, 0, len(rs))
+	for _, rate := range rs {
+		records = append(records, parser.FormatRecord(rate, c.Precision))
+	}
+	return records, nil
+}
+
+// Result: the rates of a source, or why they could not be fetched.
+type Result struct {
+	Source  string
+	Records []rates.RateRecord
+	Err     error
+}
+
+// FetchAll: fetches the rates of every source at the same time and returns
+// their results in the order of sources. A source that fails does not stop
+// the others; its error is in its Result.
+func FetchAll(ctx context.Context, sources []Source) []Result {
+	results := make([]Result, len(sources))
+	var g errgroup.Group
+	for i, src := range sources {
+		g.Go(func() error {
+			records, err := src.Fetch(ctx)
+			if err != nil {
+				err = fmt.Errorf("could not fetch the %s rates: %w", src.Name(), err)
+			}
+			results[i] = Result{Source: src.Name(), Records: records, Err: err}
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
@@ -0,0 +1,45 @@
+// Package testutil helps the tests of cbsrates run without a connection to
+// CBS, with a saved rates page and a local server to fetch it from.
+package testutil
+
+import (
+	_ "embed"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// SampleHTML: a saved CBS daily rates page, dated 03/06/2024, with the USD,
+// EUR and ZAR rates and only the GBP buying rate, as CBS often publishes it.
+// It mentions USD outside the table too, as the page does.
+//
+//go:embed testdata/cbs_sample.html
+var SampleHTML string
+
+// MockCBSServer: starts a local HTTP server that serves html at every path,
+// in place of the CBS rates page, and returns its URL and the function that
+// stops it.
+func MockCBSServer(t *testing.T, html string) (url string, cleanup func()) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(html))
+	}))
+	return srv.URL, srv.Close
+}
+
+// SkipWithoutPlaywright: skips the test with -short, or if the playwright
+// driver is not installed, as loading a page in a browser needs it.
+func SkipWithoutPlaywright(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("loads a page in a browser")
+	}
+	pw, err := playwright.Run()
+	if err != nil {
+		t.Skipf("playwright is not installed: %v", err)
+	}
+	pw.Stop()
+}